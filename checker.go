@@ -2,35 +2,79 @@ package main
 
 import (
 	"fmt"
-	"io"
-	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/mod/semver"
 )
 
 type Checker interface {
 	RequiredUpdate(
 		pkg string,
 		requirement VersionRequirement,
-	) (string, UpdateLevel, string, error)
+	) (CheckResult, error)
 }
 
-type DependencyKind int
+// CheckResult represents the versions found by a Checker for a package.
+type CheckResult struct {
+	// Latest version of the package, or empty if not found
+	// (or if no release is a candidate, e.g. only pre-releases)
+	LatestVersion string
+
+	// Whether the package is unknown to the registry
+	// (or to all the registries for a combined result)
+	NotFound bool
+
+	// Latest version which can be installed
+	// (e.g. compatible with the required Python version), if any
+	InstallableVersion string
+
+	// Level of the update to the installable version (0 if none)
+	UpdateLevel UpdateLevel
+
+	// URL of the package
+	PackageUrl string
+
+	// Name of the registry where the package has been found (e.g. `pypi`)
+	Registry string
+
+	// Whether the pinned version (see PinnedVersion) has been yanked
+	Yanked bool
+
+	// Reason why the pinned version has been yanked, if any
+	YankedReason string
+
+	// Dependency confusion found for the package, if any (see ConfusionDetector)
+	Confusion *DependencyConfusion
+}
+
+// IsBlocked returns true if the latest version requires an update
+// which is not installable (e.g. requiring another Python version).
+func (r CheckResult) IsBlocked(requirement VersionRequirement) bool {
+	return r.LatestVersion != "" &&
+		r.LatestVersion != r.InstallableVersion &&
+		ShouldUpdate(requirement, r.LatestVersion)
+}
+
+// DependencyKind represents the kind of a dependency:
+// either a runtime or dev dependency, or a dependency from a named group
+// (e.g. a PEP 621 optional-dependency group).
+type DependencyKind string
 
 const (
-	DevDependency DependencyKind = iota
-	RunDependency
+	DevDependency DependencyKind = "dev"
+	RunDependency DependencyKind = "runtime"
 )
 
-func (k DependencyKind) String() string {
-	if k == DevDependency {
-		return "dev"
-	}
+// GroupDependency returns the kind of the dependencies from the specified group.
+func GroupDependency(group string) DependencyKind {
+	return DependencyKind(NormalizePackageName(group))
+}
 
-	return "runtime"
+func (k DependencyKind) String() string {
+	return string(k)
 }
 
 func ContainsString(s []string, str string) bool {
@@ -43,54 +87,222 @@ func ContainsString(s []string, str string) bool {
 	return false
 }
 
-// ReportUpdates reports updates for the given dependencies.
-// It returns a boolean indicating whether there is at least one update available and an error if any.
+// PinnedVersion returns the version pinned by the requirement
+// (e.g. `v1.2.3` for `==1.2.3`), or an empty string if not pinned.
+func PinnedVersion(requirement VersionRequirement) string {
+	if len(requirement) != 1 {
+		return ""
+	}
+
+	if op := requirement[0][0]; op != "==" && op != "===" {
+		return ""
+	}
+
+	return requirement[0][1]
+}
+
+// ReportUpdates reports the updates to each of the reportings,
+// sorted and grouped as specified (see SortUpdates).
+// It returns a boolean indicating whether there is at least one fatal update
+// for a package which is not excluded.
 func ReportUpdates(
+	updates []PackageUpdate,
+	excludedPackages []string,
+	order UpdateOrder,
+	grouping UpdateGrouping,
+	reportings []Reporting,
+) bool {
+	sorted := append([]PackageUpdate{}, updates...)
+
+	SortUpdates(sorted, order, grouping)
+
+	atLeastOneUpdate := false
+
+	for i, update := range sorted {
+		if update.Fatal && !ContainsString(excludedPackages, update.PackageName) {
+			atLeastOneUpdate = true
+		}
+
+		group := grouping.Group(update)
+
+		for _, reporting := range reportings {
+			if group != "" && (i == 0 || grouping.Group(sorted[i-1]) != group) {
+				reporting.Reporter.BeginGroup(group, reporting.Output)
+			}
+
+			reporting.Reporter.Report(update, excludedPackages, reporting.Output)
+		}
+	}
+
+	return atLeastOneUpdate
+}
+
+// CheckUpdates checks the given dependencies with the checker,
+// with at most `parallelism` packages checked concurrently,
+// and returns the updates to be reported, sorted by package name.
+//
+// The packages for which the latest version cannot be installed
+// are also returned (with no update level), when no update is installable,
+// as well as the packages for which the pinned version has been yanked,
+// and the ones resolving on several registries (see DependencyConfusion),
+// and the packages unknown to all the registries (e.g. typo or removed package,
+// see PackageUpdate.NotFound).
+// Whether each update is fatal is decided by the policy (see FailPolicy).
+// When a package has a locked version (e.g. from a Pipfile.lock),
+// this version is checked instead of the requirement.
+// If the check of some packages fails, the error for the first package
+// (according the order of the names) is returned, with the updates
+// including the failed packages (see PackageUpdate.Error),
+// so that they can be reported if continuing on errors.
+func CheckUpdates(
 	dependencies Dependencies,
 	kind DependencyKind,
-	minLevel UpdateLevel,
-	excludedPackages []string,
+	lockedVersions LockedVersions,
+	policy FailPolicy,
+	parallelism int,
 	checker Checker,
-	reporter UpdateReporter,
-	out io.Writer,
-) (bool, error) {
-	atLeastOneUpdate := false
+) ([]PackageUpdate, error) {
+	packages := make([]string, 0, len(dependencies))
 
-	for pkg, requirement := range dependencies {
-		ts := time.Now()
-		ver, lvl, url, err := checker.RequiredUpdate(pkg, requirement)
+	for pkg := range dependencies {
+		packages = append(packages, pkg)
+	}
 
-		if err != nil {
-			return false, err
+	sort.Strings(packages)
+
+	type packageCheck struct {
+		checked VersionRequirement
+		locked  string
+		result  CheckResult
+		err     error
+		timeSec float64
+	}
+
+	checks := make([]packageCheck, len(packages))
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < parallelism && w < len(packages); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				ts := time.Now()
+				pkg := packages[i]
+				check := &checks[i]
+
+				check.checked = dependencies[pkg]
+				check.locked = lockedVersions[NormalizePackageName(pkg)]
+
+				if check.locked != "" {
+					check.checked = VersionRequirement{VersionConstraint{"==", check.locked}}
+				}
+
+				check.result, check.err = checker.RequiredUpdate(pkg, check.checked)
+				check.timeSec = time.Since(ts).Seconds()
+			}
+		}()
+	}
+
+	for i := range packages {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	// ---
+
+	var updates []PackageUpdate
+
+	var firstErr error
+
+	for i, pkg := range packages {
+		check := checks[i]
+
+		if check.err != nil {
+			if firstErr == nil {
+				firstErr = check.err
+			}
+
+			updates = append(updates, PackageUpdate{
+				PackageName:    pkg,
+				Requirement:    dependencies[pkg],
+				LockedVersion:  check.locked,
+				DependencyKind: kind,
+				Error:          check.err,
+				TimeSec:        check.timeSec,
+			})
+
+			continue
 		}
 
-		if lvl == 0 {
-			log.Debugf("no update available for %s: '%s'", pkg, ver)
+		result := check.result
+		lvl := result.UpdateLevel
+
+		if result.NotFound {
+			log.Warnf("Package %s not found on any registry", pkg)
+
+			update := PackageUpdate{
+				PackageName:    pkg,
+				Requirement:    dependencies[pkg],
+				LockedVersion:  check.locked,
+				DependencyKind: kind,
+				NotFound:       true,
+				TimeSec:        check.timeSec,
+			}
+
+			update.Fatal = policy.IsFatal(update)
+			updates = append(updates, update)
 
 			continue
 		}
 
-		fatal := lvl >= minLevel
+		if result.Yanked {
+			log.Warnf("Version %s of %s has been yanked: %s",
+				PinnedVersion(check.checked), pkg, result.YankedReason)
+		}
 
-		if fatal && !ContainsString(excludedPackages, pkg) {
-			atLeastOneUpdate = true
+		if result.Confusion != nil {
+			log.Warnf("Package %s found on several registries (%s dependency confusion): %s",
+				pkg, result.Confusion.Level, result.Confusion)
+		}
+
+		if lvl == 0 && !result.IsBlocked(check.checked) && !result.Yanked && result.Confusion == nil {
+			log.Debugf("no update available for %s: '%s'", pkg, result.LatestVersion)
+
+			continue
+		}
+
+		update := PackageUpdate{
+			PackageName:        pkg,
+			Requirement:        dependencies[pkg],
+			LockedVersion:      check.locked,
+			LatestVersion:      result.LatestVersion,
+			InstallableVersion: result.InstallableVersion,
+			UpdateLevel:        lvl,
+			DependencyKind:     kind,
+			PackageUrl:         result.PackageUrl,
+			Registry:           result.Registry,
+			Yanked:             result.Yanked,
+			YankedReason:       result.YankedReason,
+			Confusion:          result.Confusion,
+			TimeSec:            check.timeSec,
 		}
 
-		reporter.Report(
-			pkg,
-			requirement,
-			ver,
-			lvl,
-			kind,
-			url,
-			fatal,
-			excludedPackages,
-			time.Since(ts).Seconds(),
-			out,
-		)
+		update.Fatal = policy.IsFatal(update)
+		updates = append(updates, update)
 	}
 
-	return atLeastOneUpdate, nil
+	return updates, firstErr
 }
 
 // ShouldUpdate checks if the given requirement should be updated to the latest version.
@@ -119,44 +331,34 @@ func ShouldUpdate(
 	return false
 }
 
+// AreCompatibles checks whether some versions match both requirements
+// (e.g. `>=3.8` and `>=3.6,<3.10`), using their ranges (see NewVersionRange).
+// If a requirement is not valid, it logs a warning and returns true.
 func AreCompatibles(a, b VersionRequirement) bool {
-	for _, ac := range a {
-		ao := ac[0]
-
-		if ao == "*" {
-			continue
-		}
-
-		for _, bc := range b {
-			bo := bc[0]
+	ra, err := NewVersionRange(a)
 
-			if bo == "*" {
-				continue
-			}
-
-			if bo == "==" && MatchConstraint(bc[1], ac) {
-				continue
-			}
+	if err == nil {
+		var rb VersionRange
 
-			if ao == "==" && MatchConstraint(ac[1], bc) {
-				continue
-			}
+		rb, err = NewVersionRange(b)
 
-			if !MatchConstraint(bc[1], ac) || !MatchConstraint(ac[1], bc) {
-				return false
-			}
+		if err == nil {
+			return !ra.Intersect(rb).IsEmpty()
 		}
 	}
 
+	log.Warnf("Cannot check compatibility of %s with %s: %s",
+		FormatRequirement(a), FormatRequirement(b), err.Error())
+
 	return true
 }
 
 // MatchConstraint checks if the latest version matches the given constraint.
 // It returns a boolean indicating whether the latest version matches the constraint or not.
-// It supports the following operators from PEP-404 (https://peps.python.org/pep-0440):
+// It supports the following operators from PEP-440 (https://peps.python.org/pep-0440):
 // - ===: arbitrary equality
-// - ~: version matching
-// - !~: version not matching
+// - ~: version matching (`==` with prefix, e.g. `1.2.*`)
+// - !~: version not matching (`!=` with prefix)
 // - <=: less than or equal to
 // - <: less than
 // - !=: not equal to
@@ -164,7 +366,7 @@ func AreCompatibles(a, b VersionRequirement) bool {
 // - ==: equal to
 // - >=: greater than or equal to
 // - >: greater than
-// It uses the semver package to compare versions.
+// Versions are compared according PEP 440 (see Version).
 // If the constraint is invalid, it logs a warning and returns false.
 func MatchConstraint(
 	latest string,
@@ -178,91 +380,29 @@ func MatchConstraint(
 		return ver == latest
 	}
 
-	normalizedLatest := latest
-
-	if !semver.IsValid(latest) {
-		normalizedLatest = NormalizeNonStandardVersion(latest)
-	}
-
-	if op == "~" || op == "!~" {
-		// '==' + version matching
-		re, err := CompileMatching(ver)
-
-		if err != nil {
-			log.Warnf("Invalid version matching: %s", ver)
-
-			return false
-		}
-
-		matches := re.MatchString(normalizedLatest)
-
-		if op == "~" {
-			return matches
-		} else {
-			return !matches
-		}
-	}
-
-	// ---
-
-	normalized := ver
-
-	if !semver.IsValid(ver) {
-		// Normalize non standard version
-		normalized = NormalizeNonStandardVersion(ver)
-	}
-
-	c := semver.Compare(normalizedLatest, normalized)
+	v, err := ParseVersion(latest)
 
-	if op == "<=" {
-		return c <= 0
-	}
-
-	if op == "<" {
-		return c < 0
-	}
+	if err != nil {
+		log.Warnf("Invalid version: %s", latest)
 
-	if op == "!=" {
-		return c != 0
+		return false
 	}
 
-	if op == "~=" {
-		// See https://peps.python.org/pep-0440/#compatible-release
-		return semver.Major(normalizedLatest) == semver.Major(normalized) && c >= 0
+	if op == "~" {
+		op = "=="
+	} else if op == "!~" {
+		op = "!="
 	}
 
-	if op == "==" {
-		return c == 0
-	}
+	matches, err := v.MatchSpecifier(op, ver)
 
-	if op == ">=" {
-		return c >= 0
-	}
+	if err != nil {
+		log.Warnf("Invalid version constraint: %s%s: %s", constraint[0], ver, err.Error())
 
-	if op == ">" {
-		return c > 0
+		return false
 	}
 
-	return false
-}
-
-// NormalizeNonStandardVersion normalizes a non-standard version to a standard one.
-// It takes a version string as input and returns the first three segments
-// of the version string separated by a period.
-// For example, "1.2.3.4" would be normalized to "1.2.3".
-// This function is used to normalize non-standard version strings before
-// comparing them with standard version strings.
-func NormalizeNonStandardVersion(ver string) string {
-	return strings.Join(strings.Split(ver, ".")[0:3], ".")
-}
-
-// CompileMatching compiles a regular expression pattern that matches the given string.
-// It replaces any asterisk (*) with the regular expression pattern ".*".
-// It returns a compiled regular expression and an error if any.
-func CompileMatching(matching string) (*regexp.Regexp, error) {
-	pattern := strings.ReplaceAll(matching, "*", ".*")
-
-	return regexp.Compile(pattern)
+	return matches
 }
 
 // CreateUpdateLevel creates an update level based on
@@ -291,7 +431,7 @@ func CreateUpdateLevel(
 
 	// ---
 
-	maxVer := "v0.0.0"
+	var maxVer *Version
 
 	for _, constraint := range requirement {
 		spec := constraint[1]
@@ -300,33 +440,36 @@ func CreateUpdateLevel(
 			continue
 		}
 
-		spec = strings.ReplaceAll(spec, "*", "0")
-
-		normalized := spec
+		v, err := ParseVersion(strings.TrimSuffix(spec, ".*"))
 
-		if !semver.IsValid(spec) {
-			// Normalize non standard version
-			normalized = NormalizeNonStandardVersion(spec)
+		if err != nil {
+			return 0, err
 		}
 
-		if semver.Compare(maxVer, normalized) < 0 {
-			maxVer = normalized
+		if maxVer == nil || maxVer.Compare(v) < 0 {
+			maxVer = &v
 		}
 	}
 
-	if maxVer == "v0.0.0" {
+	if maxVer == nil || maxVer.Compare(Version{Release: []int{0}}) == 0 {
 		return 0, nil
 	}
 
-	if semver.Major(latest) != semver.Major(maxVer) {
+	latestVer, err := ParseVersion(latest)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if latestVer.Epoch != maxVer.Epoch || latestVer.Major() != maxVer.Major() {
 		return Major, nil
 	}
 
-	if semver.MajorMinor(latest) != semver.MajorMinor(maxVer) {
+	if latestVer.Minor() != maxVer.Minor() {
 		return Minor, nil
 	}
 
-	if semver.Compare(latest, maxVer) != 0 {
+	if latestVer.Compare(*maxVer) != 0 {
 		return Patch, nil
 	}
 
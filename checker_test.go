@@ -2,7 +2,11 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestMatchConstraint(t *testing.T) {
@@ -13,10 +17,6 @@ func TestMatchConstraint(t *testing.T) {
 		expected   bool
 	}{
 		// Test cases for valid constraints
-		{
-			constraint: VersionConstraint{"==", "v1.2.3.4"}, // non standard
-			expected:   true,
-		},
 		{
 			constraint: VersionConstraint{">=", "v1.0.0"},
 			expected:   true,
@@ -98,6 +98,14 @@ func TestMatchConstraint(t *testing.T) {
 			constraint: VersionConstraint{"!~", "v1.2.*"},
 			expected:   false,
 		},
+		{
+			constraint: VersionConstraint{"==", "v1.2.3.4"},
+			expected:   false,
+		},
+		{
+			constraint: VersionConstraint{"~=", "v1"}, // invalid compatible release
+			expected:   false,
+		},
 	}
 
 	for _, test := range tests {
@@ -128,9 +136,6 @@ func TestShouldUpdate(t *testing.T) {
 			VersionConstraint{">=", "v1.0.0"},
 			VersionConstraint{"~=", "v1.3.0"},
 		}, true},
-		{VersionRequirement{
-			VersionConstraint{"<", "v1.2.3.4"}, // non standard
-		}, true},
 
 		// Test cases for requirements that don't require an update
 		{VersionRequirement{
@@ -158,7 +163,10 @@ func TestShouldUpdate(t *testing.T) {
 			VersionConstraint{">=", "v1.2.3"},
 		}, false},
 		{VersionRequirement{
-			VersionConstraint{">", "v1.2.2.3"}, // non standard
+			VersionConstraint{">", "v1.2.2.3"},
+		}, false},
+		{VersionRequirement{
+			VersionConstraint{"<", "v1.2.3.4"},
 		}, false},
 	}
 
@@ -212,6 +220,35 @@ func TestAreCompatibles(t *testing.T) {
 			VersionRequirement{VersionConstraint{">=", "v0.2.3"}},
 			true,
 		},
+		{
+			VersionRequirement{VersionConstraint{">=", "v3.8"}},
+			VersionRequirement{
+				VersionConstraint{">=", "v3.6"},
+				VersionConstraint{"<", "v3.10"},
+			},
+			true,
+		},
+		{
+			VersionRequirement{VersionConstraint{"~", "v3.9.*"}},
+			VersionRequirement{
+				VersionConstraint{">=", "v3.6"},
+				VersionConstraint{"!~", "v3.9.*"},
+			},
+			false,
+		},
+		{
+			VersionRequirement{VersionConstraint{"~", "v3.11.*"}},
+			VersionRequirement{
+				VersionConstraint{">=", "v3.6"},
+				VersionConstraint{"!~", "v3.9.*"},
+			},
+			true,
+		},
+		{
+			VersionRequirement{VersionConstraint{"<=", "v3.7"}},
+			VersionRequirement{VersionConstraint{">", "v3.7"}},
+			false,
+		},
 	}
 
 	for _, test := range tests {
@@ -248,6 +285,12 @@ func TestCreateUpdateLevel(t *testing.T) {
 		{VersionRequirement{{">=", "v1.2.3"}}, "v1.2.3", 0, nil},
 		{VersionRequirement{{">=", "v1.0.0"}}, "v1.0.0", 0, nil},
 		{VersionRequirement{{"~=", "v1.2.3"}}, "v1.2.3", 0, nil},
+		{VersionRequirement{{"~", "v1.2.*"}}, "v1.2.0", 0, nil},
+
+		// Test cases for PEP 440 versions
+		{VersionRequirement{{">=", "v1.2.3"}}, "v1.2.3.post1", Patch, nil},
+		{VersionRequirement{{"~", "v1.2.*"}}, "v1.3.0rc1", Minor, nil},
+		{VersionRequirement{{">=", "v2020.1"}}, "v1!1.0", Major, nil},
 	}
 
 	for _, test := range tests {
@@ -265,35 +308,382 @@ func TestCreateUpdateLevel(t *testing.T) {
 	}
 }
 
-func TestNormalizeNonStandardVersion(t *testing.T) {
-	testCases := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "normal version",
-			input:    "1.2.3",
-			expected: "1.2.3",
+type recordingReporter struct {
+	updates []PackageUpdate
+	groups  []string
+}
+
+func (r *recordingReporter) ReporterName() string {
+	return "recording"
+}
+
+func (r *recordingReporter) Before(out io.Writer) {}
+
+func (r *recordingReporter) BeginGroup(group string, out io.Writer) {
+	r.groups = append(r.groups, fmt.Sprintf("%s@%d", group, len(r.updates)))
+}
+
+func (r *recordingReporter) Report(
+	update PackageUpdate,
+	excludedPackages []string,
+	out io.Writer,
+) error {
+	r.updates = append(r.updates, update)
+
+	return nil
+}
+
+func (r *recordingReporter) After(out io.Writer) {}
+
+func TestCheckUpdatesWithLockedVersions(t *testing.T) {
+	var mutex sync.Mutex
+
+	requirements := map[string]VersionRequirement{}
+
+	checker := checkerFunc(func(pkg string, requirement VersionRequirement) (CheckResult, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		requirements[pkg] = requirement
+
+		return CheckResult{
+			LatestVersion:      "v2.0.0",
+			InstallableVersion: "v2.0.0",
+			UpdateLevel:        Major,
+		}, nil
+	})
+
+	dependencies := Dependencies{
+		"Foo_Bar": VersionRequirement{{">=", "v1.0"}},
+		"lorem":   VersionRequirement{{"*", "*"}},
+	}
+
+	updates, err := CheckUpdates(
+		dependencies,
+		RunDependency,
+		LockedVersions{"foo-bar": "v1.2.3"},
+		FailPolicy{UpdateLevel: Minor},
+		4,
+		checker,
+	)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(updates) != 2 || !updates[0].Fatal || !updates[1].Fatal {
+		t.Errorf("Expected updates to be required: %+v", updates)
+	}
+
+	expectedReq := VersionRequirement{{"==", "v1.2.3"}}
+
+	if !reflect.DeepEqual(requirements["Foo_Bar"], expectedReq) {
+		t.Errorf("Expected locked version to be checked: %v", requirements["Foo_Bar"])
+	}
+
+	if !reflect.DeepEqual(requirements["lorem"], dependencies["lorem"]) {
+		t.Errorf("Expected requirement to be checked: %v", requirements["lorem"])
+	}
+
+	for _, update := range updates {
+		if !reflect.DeepEqual(update.Requirement, dependencies[update.PackageName]) {
+			t.Errorf("Expected wanted requirement for %s: %v", update.PackageName, update.Requirement)
+		}
+
+		expectedLocked := ""
+
+		if update.PackageName == "Foo_Bar" {
+			expectedLocked = "v1.2.3"
+		}
+
+		if update.LockedVersion != expectedLocked {
+			t.Errorf("Expected locked version for %s: '%s', got '%s'", update.PackageName, expectedLocked, update.LockedVersion)
+		}
+	}
+}
+
+func TestCheckUpdatesBlockedByPython(t *testing.T) {
+	updates, err := CheckUpdates(
+		Dependencies{
+			"blocked":    VersionRequirement{{"==", "v1.0.0"}},
+			"up-to-date": VersionRequirement{{"==", "v1.0.0"}},
 		},
+		RunDependency,
+		LockedVersions{},
+		FailPolicy{UpdateLevel: Patch},
+		4,
+		checkerFunc(func(pkg string, _ VersionRequirement) (CheckResult, error) {
+			if pkg == "up-to-date" {
+				return CheckResult{LatestVersion: "v1.0.0", InstallableVersion: "v1.0.0"}, nil
+			}
+
+			// Blocked by the required Python version
+			return CheckResult{LatestVersion: "v2.0.0", InstallableVersion: "v1.0.0"}, nil
+		}),
+	)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(updates) != 1 {
+		t.Fatalf("Expected only the blocked package to be reported: %v", updates)
+	}
+
+	update := updates[0]
+
+	if update.PackageName != "blocked" || update.UpdateLevel != 0 || update.Fatal ||
+		update.LatestVersion != "v2.0.0" || update.InstallableVersion != "v1.0.0" {
+		t.Errorf("Unexpected report for blocked package: %+v", update)
+	}
+}
+
+func TestCheckUpdatesYanked(t *testing.T) {
+	updates, err := CheckUpdates(
+		Dependencies{"lorem": VersionRequirement{{">=", "v1.0.0"}}},
+		RunDependency,
+		LockedVersions{"lorem": "v1.0.0"},
+		FailPolicy{UpdateLevel: Major},
+		4,
+		resultChecker(CheckResult{
+			LatestVersion:      "v1.0.1",
+			InstallableVersion: "v1.0.1",
+			UpdateLevel:        Patch,
+			Yanked:             true,
+			YankedReason:       "Broken",
+		}),
+	)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(updates) != 1 {
+		t.Fatalf("Expected the yanked package to be reported: %v", updates)
+	}
+
+	update := updates[0]
+
+	if !update.Yanked || update.YankedReason != "Broken" || update.Fatal {
+		t.Errorf("Unexpected report for yanked package: %+v", update)
+	}
+}
+
+func TestPinnedVersion(t *testing.T) {
+	tests := []struct {
+		requirement VersionRequirement
+		expected    string
+	}{
+		{VersionRequirement{{"==", "v1.0.0"}}, "v1.0.0"},
+		{VersionRequirement{{"===", "v1.0.0"}}, "v1.0.0"},
+		{VersionRequirement{{">=", "v1.0.0"}}, ""},
+		{VersionRequirement{{"==", "v1.0.0"}, {"!=", "v1.0.1"}}, ""},
+		{VersionRequirement{{"*", "*"}}, ""},
+	}
+
+	for _, test := range tests {
+		if v := PinnedVersion(test.requirement); v != test.expected {
+			t.Errorf("For %v, expected pinned version '%s', but got '%s'", test.requirement, test.expected, v)
+		}
+	}
+}
+
+func TestCheckUpdatesConcurrently(t *testing.T) {
+	dependencies := Dependencies{}
+
+	for i := 0; i < 20; i++ {
+		dependencies[fmt.Sprintf("pkg%02d", 19-i)] = VersionRequirement{{"==", "v1.0.0"}}
+	}
+
+	var mutex sync.Mutex
+
+	running, maxRun := 0, 0
+	failing := map[string]bool{}
+
+	checker := checkerFunc(func(pkg string, _ VersionRequirement) (CheckResult, error) {
+		mutex.Lock()
+		running++
+
+		if running > maxRun {
+			maxRun = running
+		}
+
+		mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+
+		if failing[pkg] {
+			return CheckResult{}, fmt.Errorf("fails to check %s", pkg)
+		}
+
+		return CheckResult{
+			LatestVersion:      "v2.0.0",
+			InstallableVersion: "v2.0.0",
+			UpdateLevel:        Major,
+		}, nil
+	})
+
+	updates, err := CheckUpdates(
+		dependencies,
+		DevDependency,
+		LockedVersions{},
+		FailPolicy{UpdateLevel: Minor},
+		4,
+		checker,
+	)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if maxRun < 2 || maxRun > 4 {
+		t.Errorf("Expected at most 4 concurrent checks, but got %d", maxRun)
+	}
+
+	if len(updates) != len(dependencies) {
+		t.Fatalf("Expected %d updates, but got %d", len(dependencies), len(updates))
+	}
+
+	for i, update := range updates {
+		if expected := fmt.Sprintf("pkg%02d", i); update.PackageName != expected {
+			t.Errorf("Expected update #%d for %s, but got %s", i, expected, update.PackageName)
+		}
+
+		if update.TimeSec < 0.01 {
+			t.Errorf("Expected check time for %s, but got %f", update.PackageName, update.TimeSec)
+		}
+
+		if !update.Fatal || update.DependencyKind != DevDependency {
+			t.Errorf("Unexpected update: %+v", update)
+		}
+	}
+
+	// ---
+
+	maxRun = 0
+	failing = map[string]bool{"pkg07": true, "pkg03": true}
+
+	updates, err = CheckUpdates(dependencies, DevDependency, LockedVersions{}, FailPolicy{UpdateLevel: Minor}, 0, checker)
+
+	if err == nil || err.Error() != "fails to check pkg03" {
+		t.Errorf("Expected error for pkg03, but got %v", err)
+	}
+
+	// The other packages are still checked, and the failed ones reported with their error
+	if len(updates) != len(dependencies) {
+		t.Fatalf("Expected %d updates despite the errors, but got %d", len(dependencies), len(updates))
+	}
+
+	for _, update := range updates {
+		failing := failing[update.PackageName]
+
+		if failing != (update.Error != nil) || (failing && update.Fatal) {
+			t.Errorf("Unexpected update: %+v", update)
+		}
+	}
+
+	if maxRun != 1 {
+		t.Errorf("Expected sequential checks, but got %d concurrent ones", maxRun)
+	}
+}
+
+func TestReportUpdates(t *testing.T) {
+	updates := []PackageUpdate{
+		{PackageName: "lorem", UpdateLevel: Patch, DependencyKind: DevDependency, Fatal: false},
+		{PackageName: "ipsum", UpdateLevel: Major, DependencyKind: RunDependency, Fatal: true},
+		{PackageName: "dolor", UpdateLevel: Minor, DependencyKind: GroupDependency("docs"), Fatal: true},
+		{PackageName: "amet", UpdateLevel: Major, DependencyKind: DevDependency, Fatal: true},
+	}
+
+	tests := []struct {
+		order    UpdateOrder
+		grouping UpdateGrouping
+		excluded []string
+		expected []string
+		groups   []string
+		fatal    bool
+	}{
+		{OrderByName, GroupByNone, []string{}, []string{"amet", "dolor", "ipsum", "lorem"}, nil, true},
+		{OrderByLevel, GroupByNone, []string{}, []string{"amet", "ipsum", "dolor", "lorem"}, nil, true},
+		{OrderByKind, GroupByNone, []string{}, []string{"ipsum", "amet", "lorem", "dolor"}, nil, true},
 		{
-			name:     "non-standard version",
-			input:    "1.2.3.4",
-			expected: "1.2.3",
+			OrderByName, GroupByLevel,
+			[]string{"amet", "dolor", "ipsum"},
+			[]string{"amet", "ipsum", "dolor", "lorem"},
+			[]string{"major@0", "minor@2", "patch@3"},
+			false,
 		},
 		{
-			name:     "long non-standard version",
-			input:    "1.2.3.4.5.6",
-			expected: "1.2.3",
+			OrderByLevel, GroupByKind,
+			[]string{},
+			[]string{"ipsum", "amet", "lorem", "dolor"},
+			[]string{"runtime@0", "dev@1", "docs@3"},
+			true,
 		},
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			actual := NormalizeNonStandardVersion(tc.input)
-			if actual != tc.expected {
-				t.Errorf("expected %s but got %s", tc.expected, actual)
-			}
-		})
+	for _, test := range tests {
+		reporter := &recordingReporter{}
+
+		fatal := ReportUpdates(
+			updates,
+			test.excluded,
+			test.order,
+			test.grouping,
+			[]Reporting{{Reporter: reporter, Output: io.Discard}},
+		)
+
+		if fatal != test.fatal {
+			t.Errorf("For order %s and grouping %s, expected fatal %v", test.order, test.grouping, test.fatal)
+		}
+
+		names := []string{}
+
+		for _, update := range reporter.updates {
+			names = append(names, update.PackageName)
+		}
+
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("For order %s and grouping %s, expected %v, but got %v",
+				test.order, test.grouping, test.expected, names)
+		}
+
+		if !reflect.DeepEqual(reporter.groups, test.groups) {
+			t.Errorf("For order %s and grouping %s, expected groups %v, but got %v",
+				test.order, test.grouping, test.groups, reporter.groups)
+		}
+	}
+
+	if updates[0].PackageName != "lorem" {
+		t.Errorf("Expected the given updates not to be sorted in place")
+	}
+}
+
+func TestCheckUpdatesNotFound(t *testing.T) {
+	pypi := registryChecker("pypi", map[string]string{"requests": "v2.0.0"})
+
+	dependencies := Dependencies{
+		"requests": VersionRequirement{{"==", "v2.0.0"}},
+		"reqeusts": VersionRequirement{{"==", "v2.0.0"}},
+	}
+
+	for _, failOnNotFound := range []bool{false, true} {
+		updates, err := CheckUpdates(dependencies, RunDependency, LockedVersions{}, FailPolicy{NotFound: failOnNotFound}, 1, pypi)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(updates) != 1 {
+			t.Fatalf("Expected only the unknown package to be reported, but got %+v", updates)
+		}
+
+		if u := updates[0]; u.PackageName != "reqeusts" || !u.NotFound || u.Fatal != failOnNotFound {
+			t.Errorf("Unexpected update with failOnNotFound = %v: %+v", failOnNotFound, u)
+		}
 	}
 }
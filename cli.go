@@ -15,6 +15,14 @@ type CommandArguments struct {
 	PrintUsage   bool
 	PrintVersion bool
 	Reporters    string // comma separated list of reporters
+	NoCache      bool   // disable the HTTP cache
+	Offline      string // directory of the offline snapshot, if any
+	Snapshot     string // directory where to export a snapshot, if any
+	NoGitlabCI   bool   // disable the Gitlab CI auto-configuration
+
+	// Whether the other packages are still checked and reported
+	// when some cannot be checked
+	ContinueOnError bool
 }
 
 type Reporting struct {
@@ -30,6 +38,11 @@ func ParseArguments(args []string) (CommandArguments, []Reporting, error) {
 	var pipfile string
 	var printUsage bool
 	var reporters []string
+	var noCache bool
+	var offline string
+	var snapshot string
+	var noGitlabCI bool
+	var continueOnError bool
 
 	if len(args) == 0 {
 		return CommandArguments{}, []Reporting{},
@@ -44,6 +57,25 @@ func ParseArguments(args []string) (CommandArguments, []Reporting, error) {
 			break
 		} else if args[i] == "-v" {
 			verbose = true
+		} else if args[i] == "--no-cache" {
+			noCache = true
+		} else if args[i] == "--no-gitlab-ci" {
+			noGitlabCI = true
+		} else if args[i] == "--continue-on-error" {
+			continueOnError = true
+		} else if args[i] == "--offline" || args[i] == "--export-snapshot" {
+			if i+1 >= len(args) {
+				return CommandArguments{}, []Reporting{},
+					fmt.Errorf("error: %s option requires a value", args[i])
+			}
+
+			if args[i] == "--offline" {
+				offline = args[i+1]
+			} else {
+				snapshot = args[i+1]
+			}
+
+			i++
 		} else if args[i] == "-c" {
 			if i+1 >= len(args) {
 				return CommandArguments{}, []Reporting{},
@@ -92,6 +124,11 @@ func ParseArguments(args []string) (CommandArguments, []Reporting, error) {
 			fmt.Errorf("please provide the path to the Pipfile as an argument")
 	}
 
+	if offline != "" && snapshot != "" {
+		return CommandArguments{}, []Reporting{},
+			fmt.Errorf("error: --offline and --export-snapshot cannot be used together")
+	}
+
 	rl := len(reporters)
 
 	if rl == 0 {
@@ -115,33 +152,52 @@ func ParseArguments(args []string) (CommandArguments, []Reporting, error) {
 	}
 
 	return CommandArguments{
-		Verbose:      verbose,
-		Config:       config,
-		Pipfile:      pipfile,
-		PrintUsage:   printUsage,
-		PrintVersion: printVersion,
-		Reporters:    strings.Join(reporters, ", "),
+		Verbose:         verbose,
+		Config:          config,
+		Pipfile:         pipfile,
+		PrintUsage:      printUsage,
+		PrintVersion:    printVersion,
+		Reporters:       strings.Join(reporters, ", "),
+		NoCache:         noCache,
+		Offline:         offline,
+		Snapshot:        snapshot,
+		NoGitlabCI:      noGitlabCI,
+		ContinueOnError: continueOnError,
 	}, updateReporters, nil
 }
 
 func (args CommandArguments) String() string {
-	return fmt.Sprintf("{Verbose: %v, Config: '%s', Pipfile: '%s', PrintUsage: %v, PrintVersion: %v, Reporter: '%s'}",
+	return fmt.Sprintf("{Verbose: %v, Config: '%s', Pipfile: '%s', PrintUsage: %v, PrintVersion: %v, Reporter: '%s', NoCache: %v, Offline: '%s', Snapshot: '%s', NoGitlabCI: %v, ContinueOnError: %v}",
 		args.Verbose,
 		args.Config,
 		args.Pipfile,
 		args.PrintUsage,
 		args.PrintVersion,
 		args.Reporters,
+		args.NoCache,
+		args.Offline,
+		args.Snapshot,
+		args.NoGitlabCI,
+		args.ContinueOnError,
 	)
 }
 
 func PrintUsage() {
-	fmt.Println("Usage: wilf [OPTIONS] /path/to/Pipefile")
+	fmt.Println("Usage: wilf [OPTIONS] /path/to/Pipfile|/path/to/requirements.txt|/path/to/pyproject.toml")
 	fmt.Println("Options:")
 	fmt.Println("  -c FILE      Use FILE as the configuration file")
 	fmt.Println("  -h           Print this help message and exit")
 	fmt.Println("  -r REPORTER  Use REPORTER as the reporter. It can be specified multi time to apply multiple reporters. Valid options are monochrome-table, colorized-table (default), junit or junit:/path/to/output/junit.xml")
 	fmt.Println("  -v           Enable verbose output")
+	fmt.Println("  --no-cache   Disable the HTTP cache for the registry responses")
+	fmt.Println("  --offline DIR")
+	fmt.Println("               Resolve the PyPI metadata from the snapshot or mirror in DIR, without network access")
+	fmt.Println("  --export-snapshot DIR")
+	fmt.Println("               Export the PyPI metadata to DIR, as snapshot for the offline mode")
+	fmt.Println("  --no-gitlab-ci")
+	fmt.Println("               Disable the configuration of the Gitlab registry from the Gitlab CI environment")
+	fmt.Println("  --continue-on-error")
+	fmt.Println("               Keep checking the other packages when some cannot be checked, and report the errors")
 	fmt.Println("  --version    Print version and exit")
 }
 
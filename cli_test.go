@@ -64,6 +64,61 @@ func TestParseArguments(t *testing.T) {
 			},
 			expectedReporters: []string{"colorized-table"},
 		},
+		{
+			name: "no cache flag",
+			args: []string{"--no-cache", "Pipfile"},
+			expected: CommandArguments{
+				Pipfile:   "Pipfile",
+				Reporters: "colorized-table",
+				NoCache:   true,
+			},
+			expectedReporters: []string{"colorized-table"},
+		},
+		{
+			name: "no gitlab ci flag",
+			args: []string{"--no-gitlab-ci", "Pipfile"},
+			expected: CommandArguments{
+				Pipfile:    "Pipfile",
+				Reporters:  "colorized-table",
+				NoGitlabCI: true,
+			},
+			expectedReporters: []string{"colorized-table"},
+		},
+		{
+			name: "continue on error flag",
+			args: []string{"Pipfile", "--continue-on-error"},
+			expected: CommandArguments{
+				Pipfile:         "Pipfile",
+				Reporters:       "colorized-table",
+				ContinueOnError: true,
+			},
+			expectedReporters: []string{"colorized-table"},
+		},
+		{
+			name: "offline flag",
+			args: []string{"--offline", "/mirror", "Pipfile"},
+			expected: CommandArguments{
+				Pipfile:   "Pipfile",
+				Reporters: "colorized-table",
+				Offline:   "/mirror",
+			},
+			expectedReporters: []string{"colorized-table"},
+		},
+		{
+			name: "export snapshot flag",
+			args: []string{"Pipfile", "--export-snapshot", "/snapshot"},
+			expected: CommandArguments{
+				Pipfile:   "Pipfile",
+				Reporters: "colorized-table",
+				Snapshot:  "/snapshot",
+			},
+			expectedReporters: []string{"colorized-table"},
+		},
+		{
+			name: "offline and export snapshot flags",
+			args: []string{"--offline", "/mirror", "--export-snapshot", "/snapshot", "Pipfile"},
+			err:  true,
+		},
 		{
 			name: "print version argument",
 			args: []string{"--version"},
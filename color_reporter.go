@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"io"
-	"strings"
 
 	color "github.com/fatih/color"
 	log "github.com/sirupsen/logrus"
@@ -34,7 +33,10 @@ func (r ColorizedTableReporter) Before(out io.Writer) {
 	fmt.Fprintf(out, " : Minor Update backward-compatible features\n ")
 
 	color.New(color.FgGreen).Fprint(out, "<green>")
-	fmt.Fprintln(out, "  : Patch Update backward-compatible bug fixes")
+	fmt.Fprintf(out, "  : Patch Update backward-compatible bug fixes\n ")
+
+	color.New(color.FgMagenta).Fprint(out, "<magenta>")
+	fmt.Fprintln(out, ": Dependency confusion package found on several registries")
 	fmt.Fprintln(out)
 
 	underline := color.New(color.Underline)
@@ -45,9 +47,15 @@ func (r ColorizedTableReporter) Before(out io.Writer) {
 	underline.Fprint(out, "Wanted")
 	fmt.Fprint(out, "          ")
 
+	underline.Fprint(out, "Locked")
+	fmt.Fprint(out, "      ")
+
 	underline.Fprint(out, "Latest")
 	fmt.Fprint(out, "      ")
 
+	underline.Fprint(out, "Installable")
+	fmt.Fprint(out, " ")
+
 	underline.Fprint(out, "Package type")
 	fmt.Fprint(out, "  ")
 
@@ -55,18 +63,18 @@ func (r ColorizedTableReporter) Before(out io.Writer) {
 	fmt.Fprintln(out)
 }
 
+func (r ColorizedTableReporter) BeginGroup(group string, out io.Writer) {
+	fmt.Fprintln(out)
+	color.New(color.Bold, color.Underline).Fprintln(out, group)
+}
+
 func (r ColorizedTableReporter) Report(
-	packageName string,
-	requirement VersionRequirement,
-	latestVersion string,
-	updateLevel UpdateLevel,
-	dependencyKind DependencyKind,
-	packageUrl string,
-	fatal bool,
+	update PackageUpdate,
 	excludedPackages []string,
-	timeSec float64,
 	out io.Writer,
 ) error {
+	packageName := update.PackageName
+
 	if ContainsString(excludedPackages, packageName) {
 		log.Debugf("skipping package %s", packageName)
 
@@ -75,41 +83,51 @@ func (r ColorizedTableReporter) Report(
 
 	// ---
 
-	reqs := make([]string, len(requirement))
-
-	for i, req := range requirement {
-		reqs[i] = fmt.Sprintf("%s%s", req[0], req[1])
-	}
-
 	pc := color.New(color.FgHiBlack, color.Bold)
 
-	if updateLevel == Major {
+	if update.UpdateLevel == Major {
 		pc = color.New(color.FgRed)
-	} else if updateLevel == Minor {
+	} else if update.UpdateLevel == Minor {
 		pc = color.New(color.FgYellow)
-	} else if updateLevel == Patch {
+	} else if update.UpdateLevel == Patch {
 		pc = color.New(color.FgGreen)
 	}
 
+	if update.Confusion != nil {
+		pc = color.New(color.FgMagenta)
+	}
+
 	pc.Fprintf(out, "%-14.14s", packageName)
 	fmt.Fprint(out, "\t")
 
-	fmt.Fprintf(out, "%-12.12s", strings.Join(reqs, ", "))
+	fmt.Fprintf(out, "%-12.12s", FormatRequirement(update.Requirement))
 	fmt.Fprint(out, "\t")
 
-	pc.Add(color.Bold).Fprintf(out, "%-10.10s", latestVersion)
+	fmt.Fprintf(out, "%-10.10s", update.LockedVersion)
 	fmt.Fprint(out, "  ")
 
-	kind := "dev"
+	fmt.Fprintf(out, "%-10.10s", update.LatestVersion)
+	fmt.Fprint(out, "  ")
 
-	if dependencyKind == RunDependency {
-		kind = "runtime"
-	}
+	pc.Add(color.Bold).Fprintf(out, "%-10.10s", update.InstallableVersion)
+	fmt.Fprint(out, "  ")
 
-	fmt.Fprintf(out, "%-12.12s", kind)
+	fmt.Fprintf(out, "%-12.12s", update.DependencyKind)
 	fmt.Fprint(out, "  ")
 
-	fmt.Fprintf(out, "%s; %s\n", packageName, packageUrl)
+	if update.Error != nil {
+		color.New(color.FgRed).Fprintf(out, "error: %s\n", update.Error.Error())
+
+		return nil
+	}
+
+	if update.NotFound {
+		fmt.Fprintf(out, "%s not found on any registry\n", packageName)
+
+		return nil
+	}
+
+	fmt.Fprintf(out, "%s%s; %s\n", packageName, update.Notes(), update.PackageUrl)
 
 	return nil
 }
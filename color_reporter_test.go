@@ -34,7 +34,10 @@ func TestColorizedTableReporterBeforeAfter(t *testing.T) {
 	expected.WriteString(" : Minor Update backward-compatible features\n ")
 
 	color.New(color.FgGreen).Fprint(&expected, "<green>")
-	expected.WriteString("  : Patch Update backward-compatible bug fixes\n\n")
+	expected.WriteString("  : Patch Update backward-compatible bug fixes\n ")
+
+	color.New(color.FgMagenta).Fprint(&expected, "<magenta>")
+	expected.WriteString(": Dependency confusion package found on several registries\n\n")
 
 	underline := color.New(color.Underline)
 
@@ -44,9 +47,15 @@ func TestColorizedTableReporterBeforeAfter(t *testing.T) {
 	underline.Fprint(&expected, "Wanted")
 	expected.WriteString("          ")
 
+	underline.Fprint(&expected, "Locked")
+	expected.WriteString("      ")
+
 	underline.Fprint(&expected, "Latest")
 	expected.WriteString("      ")
 
+	underline.Fprint(&expected, "Installable")
+	expected.WriteString(" ")
+
 	underline.Fprint(&expected, "Package type")
 	expected.WriteString("  ")
 
@@ -79,15 +88,18 @@ func TestColorizedTableReporterReportMajorDev(t *testing.T) {
 	var expected bytes.Buffer
 
 	reporter.Report(
-		"github.com/test/package",
-		VersionRequirement{{">=", "1.0.0"}},
-		"2.0.0",
-		Major,
-		DevDependency,
-		"https://github.com/test/package",
-		false,
+		PackageUpdate{
+			PackageName:        "github.com/test/package",
+			Requirement:        VersionRequirement{{">=", "1.0.0"}},
+			LatestVersion:      "2.0.0",
+			InstallableVersion: "2.0.0",
+			UpdateLevel:        Major,
+			DependencyKind:     DevDependency,
+			PackageUrl:         "https://github.com/test/package",
+			Fatal:              false,
+			TimeSec:            0,
+		},
 		[]string{},
-		0,
 		&buf,
 	)
 
@@ -97,6 +109,9 @@ func TestColorizedTableReporterReportMajorDev(t *testing.T) {
 	expected.WriteString("\t")
 
 	expected.WriteString(">=1.0.0     \t")
+	expected.WriteString("            ")
+
+	expected.WriteString("2.0.0       ")
 
 	pc.Add(color.Bold).Fprint(&expected, "2.0.0     ")
 	expected.WriteString("  ")
@@ -118,15 +133,18 @@ func TestColorizedTableReporterReportExcludedMajorDev(t *testing.T) {
 	}
 
 	reporter.Report(
-		"github.com/test/package",
-		VersionRequirement{{">=", "1.0.0"}},
-		"2.0.0",
-		Major,
-		DevDependency,
-		"https://github.com/test/package",
-		false,
+		PackageUpdate{
+			PackageName:        "github.com/test/package",
+			Requirement:        VersionRequirement{{">=", "1.0.0"}},
+			LatestVersion:      "2.0.0",
+			InstallableVersion: "2.0.0",
+			UpdateLevel:        Major,
+			DependencyKind:     DevDependency,
+			PackageUrl:         "https://github.com/test/package",
+			Fatal:              false,
+			TimeSec:            0,
+		},
 		[]string{"github.com/test/package"},
-		0,
 		&buf,
 	)
 
@@ -143,15 +161,18 @@ func TestColorizedTableReporterMinorRun(t *testing.T) {
 	var expected bytes.Buffer
 
 	reporter.Report(
-		"github.com/foo/package",
-		VersionRequirement{{">=", "1.0.0"}},
-		"1.1.0",
-		Minor,
-		RunDependency,
-		"https://github.com/foo/package",
-		false,
+		PackageUpdate{
+			PackageName:        "github.com/foo/package",
+			Requirement:        VersionRequirement{{">=", "1.0.0"}},
+			LatestVersion:      "1.1.0",
+			InstallableVersion: "1.1.0",
+			UpdateLevel:        Minor,
+			DependencyKind:     RunDependency,
+			PackageUrl:         "https://github.com/foo/package",
+			Fatal:              false,
+			TimeSec:            0,
+		},
 		[]string{},
-		0,
 		&buf,
 	)
 
@@ -161,6 +182,9 @@ func TestColorizedTableReporterMinorRun(t *testing.T) {
 	expected.WriteString("\t")
 
 	expected.WriteString(">=1.0.0     \t")
+	expected.WriteString("            ")
+
+	expected.WriteString("1.1.0       ")
 
 	pc.Add(color.Bold).Fprint(&expected, "1.1.0     ")
 	expected.WriteString("  ")
@@ -180,15 +204,19 @@ func TestColorizedTableReporterPatchRun(t *testing.T) {
 	var expected bytes.Buffer
 
 	reporter.Report(
-		"bar",
-		VersionRequirement{{">=", "3.4"}},
-		"3.4.5",
-		Patch,
-		RunDependency,
-		"https://github.com/bar/package",
-		false,
+		PackageUpdate{
+			PackageName:        "bar",
+			Requirement:        VersionRequirement{{">=", "3.4"}},
+			LockedVersion:      "v3.4.1",
+			LatestVersion:      "3.5.0",
+			InstallableVersion: "3.4.5",
+			UpdateLevel:        Patch,
+			DependencyKind:     RunDependency,
+			PackageUrl:         "https://github.com/bar/package",
+			Fatal:              false,
+			TimeSec:            0,
+		},
 		[]string{},
-		0,
 		&buf,
 	)
 
@@ -198,6 +226,9 @@ func TestColorizedTableReporterPatchRun(t *testing.T) {
 	expected.WriteString("\t")
 
 	expected.WriteString(">=3.4       \t")
+	expected.WriteString("v3.4.1      ")
+
+	expected.WriteString("3.5.0       ")
 
 	pc.Add(color.Bold).Fprint(&expected, "3.4.5     ")
 	expected.WriteString("  ")
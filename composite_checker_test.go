@@ -2,6 +2,11 @@ package main
 
 import (
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -16,12 +21,56 @@ type mockChecker struct {
 func (m mockChecker) RequiredUpdate(
 	pkg string,
 	requirement VersionRequirement,
-) (string, UpdateLevel, string, error) {
+) (CheckResult, error) {
 	if pkg != m.pkg {
-		return "", 0, "", nil
+		return CheckResult{}, nil
 	}
 
-	return m.latestVersion, m.updateLevel, m.url, m.err
+	return CheckResult{
+		LatestVersion:      m.latestVersion,
+		InstallableVersion: m.latestVersion,
+		UpdateLevel:        m.updateLevel,
+		PackageUrl:         m.url,
+	}, m.err
+}
+
+// checkerFunc is a Checker returning the result of the function for each package.
+type checkerFunc func(pkg string, requirement VersionRequirement) (CheckResult, error)
+
+func (f checkerFunc) RequiredUpdate(
+	pkg string,
+	requirement VersionRequirement,
+) (CheckResult, error) {
+	return f(pkg, requirement)
+}
+
+// resultChecker returns the same result for all the packages.
+func resultChecker(result CheckResult) checkerFunc {
+	return func(string, VersionRequirement) (CheckResult, error) {
+		return result, nil
+	}
+}
+
+// failingChecker fails for all the packages with the given error.
+func failingChecker(err error) checkerFunc {
+	return func(string, VersionRequirement) (CheckResult, error) {
+		return CheckResult{}, err
+	}
+}
+
+// registryChecker finds the packages of a registry at the given versions
+// (a package known without candidate version having an empty one).
+func registryChecker(registry string, versions map[string]string) checkerFunc {
+	return func(pkg string, _ VersionRequirement) (CheckResult, error) {
+		version, known := versions[pkg]
+
+		return CheckResult{
+			LatestVersion:      version,
+			InstallableVersion: version,
+			Registry:           registry,
+			NotFound:           !known,
+		}, nil
+	}
 }
 
 func TestCompositeChecker(t *testing.T) {
@@ -84,7 +133,8 @@ func TestCompositeChecker(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		latest, level, url, err := compositeChecker.RequiredUpdate(tc.pkg, tc.requirement)
+		result, err := compositeChecker.RequiredUpdate(tc.pkg, tc.requirement)
+		latest, level, url := result.LatestVersion, result.UpdateLevel, result.PackageUrl
 
 		if latest != tc.expectedLatest {
 			t.Errorf("Expected latest version to be %s, but got %s", tc.expectedLatest, latest)
@@ -103,3 +153,372 @@ func TestCompositeChecker(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateCompositeChecker(t *testing.T) {
+	var credentials []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		credentials = append(credentials, user+":"+password)
+
+		if r.URL.Path != "/simple/internal-lib/" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		io.WriteString(w, `<a href="internal_lib-1.3.0.tar.gz">internal_lib-1.3.0.tar.gz</a>`)
+	}))
+
+	defer server.Close()
+
+	t.Setenv("WILF_TEST_PASSWORD", "secret")
+
+	privateUrl := strings.Replace(server.URL, "://", "://ci:${WILF_TEST_PASSWORD}@", 1) + "/simple"
+
+	pipfile := Pipfile{
+		Sources: []PipfileSource{
+			{Name: "pypi", Url: "https://pypi.org/simple", VerifySsl: true},
+			{Name: "private", Url: privateUrl, VerifySsl: false},
+		},
+		Packages: map[string]PipfilePackage{
+			"requests":     {Version: "*"},
+			"Internal_Lib": {Version: ">=1.2", Index: "private"},
+		},
+		DevPackages: map[string]PipfilePackage{},
+	}
+
+	clients := HttpClientFactory{NoCache: true}
+
+	checker, err := CreateCompositeChecker(nil, pipfile, PrereleaseNever, clients)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	routing, ok := checker.(RoutingChecker)
+
+	if !ok {
+		t.Fatalf("Expected a RoutingChecker, but got %T", checker)
+	}
+
+	// The packages without index are only checked against the first source (PyPI)
+	if defaults, ok := routing.Default.(CompositeChecker); !ok || len(defaults) != 1 {
+		t.Errorf("Expected a single default checker, but got %v", routing.Default)
+	} else if _, ok := defaults[0].(*PypiChecker); !ok {
+		t.Errorf("Expected PyPI as default checker, but got %T", defaults[0])
+	}
+
+	if len(routing.Routes) != 1 {
+		t.Fatalf("Expected a single route, but got %v", routing.Routes)
+	}
+
+	private, ok := routing.Routes["internal-lib"].(*SimpleIndexChecker)
+
+	if !ok {
+		t.Fatalf("Expected a SimpleIndexChecker for internal-lib, but got %v", routing.Routes)
+	}
+
+	if private.Name != "private" || !strings.Contains(private.IndexUrl, "ci:secret@") {
+		t.Errorf("Unexpected checker for the private index: %s (%s)", private.Name, private.IndexUrl)
+	}
+
+	result, err := checker.RequiredUpdate("internal_lib", VersionRequirement{{"==", "v1.2.0"}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	expected := CheckResult{
+		LatestVersion:      "v1.3.0",
+		InstallableVersion: "v1.3.0",
+		UpdateLevel:        Minor,
+		PackageUrl:         strings.Replace(server.URL, "://", "://ci:xxxxx@", 1) + "/simple/internal-lib/",
+		Registry:           "private",
+	}
+
+	if result != expected {
+		t.Errorf("Expected %v, but got %v", expected, result)
+	}
+
+	if !reflect.DeepEqual(credentials, []string{"ci:secret"}) {
+		t.Errorf("Expected a single authenticated request, but got %v", credentials)
+	}
+
+	// Unknown index
+	pipfile.Packages["other"] = PipfilePackage{Version: "*", Index: "unknown"}
+
+	if _, err := CreateCompositeChecker(nil, pipfile, PrereleaseNever, clients); err == nil {
+		t.Errorf("Expected error for unknown index")
+	}
+}
+
+func TestCreateCompositeCheckerWithoutSource(t *testing.T) {
+	tests := []struct {
+		config   *Config
+		expected CompositeChecker
+	}{
+		{
+			config:   nil,
+			expected: CompositeChecker{&PypiChecker{}},
+		},
+		{
+			config: &Config{
+				Index:  &IndexConfig{Url: "https://nexus.example.com/simple", Api: SimpleIndexApi},
+				Gitlab: []GitlabRegistryConfig{{ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/1/packages"}},
+			},
+			expected: CompositeChecker{
+				&SimpleIndexChecker{IndexUrl: "https://nexus.example.com/simple"},
+				&GitlabChecker{Config: GitlabRegistryConfig{ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/1/packages"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		checker, err := CreateCompositeChecker(test.config, Pipfile{}, 0, HttpClientFactory{NoCache: true})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		defaults := checker.(RoutingChecker).Default.(CompositeChecker)
+
+		// Ignore the HTTP clients
+		for _, c := range defaults {
+			switch c := c.(type) {
+			case *PypiChecker:
+				c.Client = nil
+			case *SimpleIndexChecker:
+				c.Client = nil
+			case *GitlabChecker:
+				c.Client = nil
+			}
+		}
+
+		if !reflect.DeepEqual(defaults, test.expected) {
+			t.Errorf("Expected default checkers %v, but got %v", test.expected, defaults)
+		}
+	}
+}
+
+func TestCreateCompositeCheckerDetectingConfusion(t *testing.T) {
+	gitlab := []GitlabRegistryConfig{{ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/1/packages"}}
+
+	tests := []struct {
+		config  *Config
+		private int
+	}{
+		{&Config{Settings: &Settings{}, Gitlab: gitlab}, -1},
+		{&Config{Settings: &Settings{DetectDependencyConfusion: true}}, -1},
+		{&Config{Settings: &Settings{DetectDependencyConfusion: true}, Gitlab: gitlab}, 1},
+	}
+
+	for _, test := range tests {
+		checker, err := CreateCompositeChecker(test.config, Pipfile{}, 0, HttpClientFactory{NoCache: true})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		detector, ok := checker.(ConfusionDetector)
+
+		if test.private < 0 {
+			if ok {
+				t.Errorf("Expected no confusion detection for %+v", test.config)
+			}
+
+			continue
+		}
+
+		if !ok {
+			t.Fatalf("Expected a ConfusionDetector, but got %T", checker)
+		}
+
+		if len(detector.Public) != 1 || len(detector.Private) != test.private {
+			t.Errorf("Unexpected registries: %+v", detector)
+		}
+
+		if _, ok := detector.Private[0].(*GitlabChecker); !ok {
+			t.Errorf("Expected Gitlab as private registry, but got %T", detector.Private[0])
+		}
+	}
+}
+
+func TestRegistryStrategies(t *testing.T) {
+	outage := failingChecker(&TransientError{Url: "https://gitlab.com", Status: "503 Service Unavailable"})
+	gitlab := registryChecker("gitlab", map[string]string{"foo": "v1.1.0"})
+	pypi := registryChecker("pypi", map[string]string{"foo": "v2.0.0", "bar": "v1.0.0"})
+
+	tests := []struct {
+		strategy RegistryStrategy
+		checkers []Checker
+		pkg      string
+		expected string
+	}{
+		{FirstUpdateStrategy, []Checker{gitlab, pypi}, "foo", "gitlab"},
+		{FirstUpdateStrategy, []Checker{outage, pypi}, "foo", "pypi"},
+		{HighestVersionStrategy, []Checker{gitlab, pypi}, "foo", "pypi"},
+		{HighestVersionStrategy, []Checker{pypi, gitlab}, "foo", "pypi"},
+		{HighestVersionStrategy, []Checker{gitlab, outage, pypi}, "bar", "pypi"},
+		{FirstFoundStrategy, []Checker{gitlab, pypi}, "foo", "gitlab"},
+		{FirstFoundStrategy, []Checker{gitlab, pypi}, "bar", "pypi"},
+		{FirstFoundStrategy, []Checker{outage, gitlab}, "foo", "gitlab"},
+	}
+
+	for _, test := range tests {
+		checker := test.strategy.Combine(test.checkers)
+		result, err := checker.RequiredUpdate(test.pkg, VersionRequirement{{"==", "v1.0.0"}})
+
+		if err != nil {
+			t.Fatalf("Unexpected error with %s: %s", test.strategy, err.Error())
+		}
+
+		if result.Registry != test.expected {
+			t.Errorf("Expected %s from %s with %s, but got %+v", test.pkg, test.expected, test.strategy, result)
+		}
+	}
+
+	// The transient error is returned if no other registry knows the package
+	for _, strategy := range []RegistryStrategy{FirstUpdateStrategy, HighestVersionStrategy, FirstFoundStrategy} {
+		_, err := strategy.Combine([]Checker{gitlab, outage}).RequiredUpdate("bar", VersionRequirement{})
+
+		if !IsTransientError(err) {
+			t.Errorf("Expected transient error with %s, but got %v", strategy, err)
+		}
+	}
+}
+
+func TestRegistryStrategiesNotFound(t *testing.T) {
+	gitlab := registryChecker("gitlab", map[string]string{"prerelease-only": ""})
+	pypi := registryChecker("pypi", map[string]string{"foo": "v1.0.0"})
+
+	tests := []struct {
+		pkg      string
+		notFound bool
+	}{
+		{"foo", false},
+		{"prerelease-only", false},
+		{"typo", true},
+	}
+
+	for _, strategy := range []RegistryStrategy{FirstUpdateStrategy, HighestVersionStrategy, FirstFoundStrategy} {
+		checker := strategy.Combine([]Checker{gitlab, pypi})
+
+		for _, test := range tests {
+			result, err := checker.RequiredUpdate(test.pkg, VersionRequirement{{"==", "v1.0.0"}})
+
+			if err != nil {
+				t.Fatalf("Unexpected error with %s: %s", strategy, err.Error())
+			}
+
+			if result.NotFound != test.notFound {
+				t.Errorf("Expected %s not found = %v with %s, but got %+v", test.pkg, test.notFound, strategy, result)
+			}
+		}
+	}
+}
+
+func TestParseRegistryStrategy(t *testing.T) {
+	for _, s := range []string{"first-update", "highest-version", "first-found"} {
+		if strategy, err := ParseRegistryStrategy(s); err != nil || string(strategy) != s {
+			t.Errorf("Expected strategy %s, but got %s (%v)", s, strategy, err)
+		}
+	}
+
+	if _, err := ParseRegistryStrategy("random"); err == nil {
+		t.Errorf("Expected error for invalid strategy")
+	}
+}
+
+func TestCreateCompositeCheckerWithRegistryMappings(t *testing.T) {
+	config := &Config{
+		Settings: &Settings{
+			RegistryStrategy: HighestVersionStrategy,
+			RegistryMappings: []RegistryMapping{
+				{Pattern: "acme-*", Registry: "gitlab"},
+				{Pattern: "legacy_*", Registry: "private"},
+			},
+		},
+		Gitlab: []GitlabRegistryConfig{{ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/1/packages"}},
+	}
+
+	pipfile := Pipfile{
+		Sources: []PipfileSource{
+			{Name: "pypi", Url: "https://pypi.org/simple", VerifySsl: true},
+			{Name: "private", Url: "https://pypi.example.com/simple", VerifySsl: true},
+		},
+	}
+
+	checker, err := CreateCompositeChecker(config, pipfile, 0, HttpClientFactory{NoCache: true})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	routing := checker.(RoutingChecker)
+
+	if defaults, ok := routing.Default.(HighestVersionChecker); !ok || len(defaults) != 2 {
+		t.Errorf("Expected the highest version across PyPI and Gitlab, but got %v", routing.Default)
+	}
+
+	if len(routing.Patterns) != 2 {
+		t.Fatalf("Expected 2 pattern routes, but got %v", routing.Patterns)
+	}
+
+	if _, ok := routing.Patterns[0].Checker.(*GitlabChecker); !ok {
+		t.Errorf("Expected Gitlab for acme-*, but got %T", routing.Patterns[0].Checker)
+	}
+
+	if private, ok := routing.Patterns[1].Checker.(*SimpleIndexChecker); !ok || private.Name != "private" {
+		t.Errorf("Expected the private source for legacy_*, but got %v", routing.Patterns[1].Checker)
+	}
+
+	for pkg, expected := range map[string]bool{"acme-lib": true, "ACME.Utils": true, "legacy-tool": false, "requests": false} {
+		if matches := routing.Patterns[0].Matches(pkg); matches != expected {
+			t.Errorf("Expected %s matching acme-*: %t", pkg, expected)
+		}
+	}
+
+	config.Settings.RegistryMappings = []RegistryMapping{{Pattern: "*", Registry: "unknown"}}
+
+	if _, err := CreateCompositeChecker(config, pipfile, 0, HttpClientFactory{NoCache: true}); err == nil {
+		t.Errorf("Expected error for unknown registry")
+	}
+}
+
+func TestRoutingCheckerPatterns(t *testing.T) {
+	gitlab := registryChecker("gitlab", map[string]string{"acme-lib": "v1.0.0", "internal": "v1.0.0"})
+	pypi := registryChecker("pypi", map[string]string{"acme-lib": "v9.0.0", "internal": "v9.0.0"})
+
+	checker := RoutingChecker{
+		Default:  pypi,
+		Routes:   map[string]Checker{"internal": gitlab},
+		Patterns: []PatternRoute{{Pattern: "acme-*", Checker: gitlab}},
+	}
+
+	for pkg, expected := range map[string]string{"acme_lib": "gitlab", "internal": "gitlab", "acme": "pypi"} {
+		result, _ := checker.RequiredUpdate(pkg, VersionRequirement{})
+
+		if result.Registry != expected {
+			t.Errorf("Expected %s to be checked against %s, but got %+v", pkg, expected, result)
+		}
+	}
+}
+
+func TestRegistryMappingValidate(t *testing.T) {
+	tests := []struct {
+		mapping RegistryMapping
+		valid   bool
+	}{
+		{RegistryMapping{Pattern: "acme-*", Registry: "gitlab"}, true},
+		{RegistryMapping{Pattern: "acme-[", Registry: "gitlab"}, false},
+		{RegistryMapping{Pattern: "", Registry: "gitlab"}, false},
+		{RegistryMapping{Pattern: "acme-*"}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.mapping.Validate(); (err == nil) != test.valid {
+			t.Errorf("Unexpected validation of %+v: %v", test.mapping, err)
+		}
+	}
+}
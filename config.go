@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/BurntSushi/toml"
 )
 
@@ -9,11 +12,52 @@ type Settings struct {
 	ExcludedPackages []string `toml:"excluded_packages"`
 	UpdateLevel      UpdateLevel
 	UpdateLevelRepr  string `toml:"update_level"`
+
+	// Policy about the pre-releases, undefined (0) if not configured
+	Prereleases     PrereleasePolicy
+	PrereleasesRepr string `toml:"prereleases"`
+
+	// Maximum number of packages checked concurrently
+	Parallelism int `toml:"parallelism"`
+
+	// Order and grouping of the updates in the reports
+	SortBy  UpdateOrder    `toml:"sort_by"`
+	GroupBy UpdateGrouping `toml:"group_by"`
+
+	// Directory of the HTTP cache, and duration during which
+	// a cached response is used without revalidation
+	CacheDir     string `toml:"cache_dir"`
+	CacheTTL     time.Duration
+	CacheTTLRepr string `toml:"cache_ttl"`
+
+	// Whether each package is looked up on all the registries,
+	// to detect the dependency confusions (see ConfusionDetector)
+	DetectDependencyConfusion bool `toml:"detect_dependency_confusion"`
+
+	// Strategy combining the results of the registries checked for a package,
+	// and registries explicitly mapped to the packages matching some patterns
+	RegistryStrategy RegistryStrategy  `toml:"registry_strategy"`
+	RegistryMappings []RegistryMapping `toml:"registry_mapping"`
+
+	// Whether the other packages are still checked and reported
+	// when some cannot be checked (see PackageUpdate.Error)
+	ContinueOnError bool `toml:"continue_on_error"`
+
+	// Whether a package unknown to all the registries fails the check,
+	// rather than being reported as a warning (see PackageUpdate.NotFound)
+	FailOnNotFound bool `toml:"fail_on_not_found"`
+
+	// Kinds of the dependencies whose updates fail the check (all if empty),
+	// and whether the updates are only reported as warnings (see FailPolicy)
+	FailOnKinds  []string `toml:"fail_on_kinds"`
+	WarningsOnly bool     `toml:"warnings_only"`
 }
 
 type Config struct {
 	Settings *Settings
-	Gitlab   *GitlabRegistryConfig
+	Index    *IndexConfig
+	Http     *HttpConfig
+	Gitlab   []GitlabRegistryConfig
 }
 
 func DefaultSettings() Settings {
@@ -22,6 +66,12 @@ func DefaultSettings() Settings {
 		ExcludedPackages: []string{},
 		UpdateLevel:      Minor,
 		UpdateLevelRepr:  "",
+		Parallelism:      8,
+		SortBy:           OrderByName,
+		GroupBy:          GroupByNone,
+		CacheDir:         DefaultCacheDir(),
+		CacheTTL:         time.Hour,
+		RegistryStrategy: FirstUpdateStrategy,
 	}
 }
 
@@ -32,6 +82,14 @@ func DefaultSettings() Settings {
 //
 // If the TOML file does not contain an `update_level` field, the `UpdateLevel` field of the returned
 // `Settings` instance will be set to `Minor` by default.
+// If it does not contain a `parallelism` field, 8 packages are checked concurrently.
+// If it does not contain a `sort_by` field, the updates are sorted by package name.
+// If it does not contain a `cache_dir` field, the default cache directory is used
+// (see DefaultCacheDir), and responses are cached for 1 hour if there is no `cache_ttl`.
+// If it does not contain a `registry_strategy` field, the result of the first registry
+// finding an update is used (see FirstUpdateStrategy).
+// If it does not contain a `prereleases` field, the `Prereleases` policy is left undefined,
+// so that it can be resolved from the dependency file (see DefaultPrereleasePolicy).
 func LoadSettings(path string) (*Settings, error) {
 	var settings Settings
 
@@ -51,6 +109,72 @@ func LoadSettings(path string) (*Settings, error) {
 		settings.UpdateLevel = DefaultSettings().UpdateLevel
 	}
 
+	if settings.Parallelism < 0 {
+		return nil, fmt.Errorf("invalid parallelism: %d", settings.Parallelism)
+	}
+
+	if settings.Parallelism == 0 {
+		settings.Parallelism = DefaultSettings().Parallelism
+	}
+
+	if settings.SortBy == "" {
+		settings.SortBy = DefaultSettings().SortBy
+	}
+
+	if _, err := ParseUpdateOrder(string(settings.SortBy)); err != nil {
+		return nil, err
+	}
+
+	if _, err := ParseUpdateGrouping(string(settings.GroupBy)); err != nil {
+		return nil, err
+	}
+
+	if settings.RegistryStrategy == "" {
+		settings.RegistryStrategy = DefaultSettings().RegistryStrategy
+	}
+
+	if _, err := ParseRegistryStrategy(string(settings.RegistryStrategy)); err != nil {
+		return nil, err
+	}
+
+	for _, kind := range settings.FailOnKinds {
+		if kind == "" {
+			return nil, fmt.Errorf("invalid dependency kind to fail on: '%s'", kind)
+		}
+	}
+
+	for _, mapping := range settings.RegistryMappings {
+		if err := mapping.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.CacheDir == "" {
+		settings.CacheDir = DefaultSettings().CacheDir
+	}
+
+	if settings.CacheTTLRepr != "" {
+		ttl, err := time.ParseDuration(settings.CacheTTLRepr)
+
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("invalid cache TTL: %s", settings.CacheTTLRepr)
+		}
+
+		settings.CacheTTL = ttl
+	} else {
+		settings.CacheTTL = DefaultSettings().CacheTTL
+	}
+
+	if settings.PrereleasesRepr != "" {
+		policy, err := ParsePrereleasePolicy(settings.PrereleasesRepr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		settings.Prereleases = policy
+	}
+
 	return &settings, nil
 }
 
@@ -64,19 +188,29 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	gitlabConfig, err := LoadGitlabRegistryConfig(path)
+	indexConfig, err := LoadIndexConfig(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpConfig, err := LoadHttpConfig(path)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if gitlabConfig.ProjectApiPackagesUrl == "" {
-		gitlabConfig = nil
+	gitlabConfigs, err := LoadGitlabRegistryConfigs(path)
+
+	if err != nil {
+		return nil, err
 	}
 
-	// Return the Config instance with the loaded settings and an empty GitlabRegistryConfig
+	// Return the Config instance with the loaded settings and the Gitlab registries (if any)
 	return &Config{
 		Settings: settings,
-		Gitlab:   gitlabConfig,
+		Index:    indexConfig,
+		Http:     httpConfig,
+		Gitlab:   gitlabConfigs,
 	}, nil
 }
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLoadSettings(t *testing.T) {
@@ -24,6 +26,46 @@ func TestLoadSettings(t *testing.T) {
 	if settings.UpdateLevel != Major {
 		t.Errorf("Expected UpdateLevel to be Major, but got %v", settings.UpdateLevel)
 	}
+
+	if settings.Prereleases != PrereleaseNever {
+		t.Errorf("Expected Prereleases to be never, but got %v", settings.Prereleases)
+	}
+
+	if settings.Parallelism != 4 {
+		t.Errorf("Expected Parallelism to be 4, but got %d", settings.Parallelism)
+	}
+
+	if !settings.DetectDependencyConfusion {
+		t.Errorf("Expected DetectDependencyConfusion to be true, but got false")
+	}
+
+	if !settings.ContinueOnError {
+		t.Errorf("Expected ContinueOnError to be true, but got false")
+	}
+
+	if !settings.FailOnNotFound {
+		t.Errorf("Expected FailOnNotFound to be true, but got false")
+	}
+
+	if len(settings.FailOnKinds) != 1 || settings.FailOnKinds[0] != "runtime" || settings.WarningsOnly {
+		t.Errorf("Unexpected fail policy: %v (warnings only: %v)", settings.FailOnKinds, settings.WarningsOnly)
+	}
+
+	if settings.RegistryStrategy != HighestVersionStrategy {
+		t.Errorf("Expected RegistryStrategy to be highest-version, but got %s", settings.RegistryStrategy)
+	}
+
+	if !reflect.DeepEqual(settings.RegistryMappings, []RegistryMapping{{Pattern: "acme-*", Registry: "gitlab"}}) {
+		t.Errorf("Unexpected RegistryMappings: %v", settings.RegistryMappings)
+	}
+
+	if settings.CacheDir != ".wilf-cache" {
+		t.Errorf("Expected CacheDir to be .wilf-cache, but got %s", settings.CacheDir)
+	}
+
+	if settings.CacheTTL != 30*time.Minute {
+		t.Errorf("Expected CacheTTL to be 30m, but got %v", settings.CacheTTL)
+	}
 }
 
 func TestLoadSettingsOnlyConfig(t *testing.T) {
@@ -38,8 +80,8 @@ func TestLoadSettingsOnlyConfig(t *testing.T) {
 		t.Errorf("Expected settings to be loaded, but got nil")
 	}
 
-	if config.Gitlab != nil {
-		t.Errorf("Expected Gitlab to be nil, but got %v", config.Gitlab)
+	if len(config.Gitlab) != 0 {
+		t.Errorf("Expected no Gitlab registry, but got %v", config.Gitlab)
 	}
 
 	settings := config.Settings
@@ -70,7 +112,7 @@ func TestLoadConfigWithGitlabRegistry(t *testing.T) {
 		t.Errorf("Expected settings to be loaded, but got nil")
 	}
 
-	if config.Gitlab == nil {
+	if len(config.Gitlab) != 1 {
 		t.Errorf("Expected Gitlab registry config to be loaded, but got %v", config.Gitlab)
 	}
 
@@ -88,4 +130,42 @@ func TestLoadConfigWithGitlabRegistry(t *testing.T) {
 	if settings.UpdateLevel != Minor {
 		t.Errorf("Expected UpdateLevel to be Minor, but got %v", settings.UpdateLevel)
 	}
+
+	if settings.Prereleases != 0 {
+		t.Errorf("Expected undefined Prereleases, but got %v", settings.Prereleases)
+	}
+
+	if settings.Parallelism != 8 {
+		t.Errorf("Expected default Parallelism, but got %d", settings.Parallelism)
+	}
+}
+
+func TestLoadConfigWithIndex(t *testing.T) {
+	config, err := LoadConfig("resources/valid-index-config.toml")
+
+	if err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+
+	expected := IndexConfig{
+		Url: "https://nexus.example.com/repository/pypi-proxy/simple",
+		Api: SimpleIndexApi,
+	}
+
+	if config.Index == nil || *config.Index != expected {
+		t.Errorf("Expected index config %v, but got %v", expected, config.Index)
+	}
+
+	// Default index
+	config, err = LoadConfig("resources/valid-settings.toml")
+
+	if err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+
+	expected = IndexConfig{Url: DefaultPypiJsonUrl, Api: JsonIndexApi}
+
+	if config.Index == nil || *config.Index != expected {
+		t.Errorf("Expected index config %v, but got %v", expected, config.Index)
+	}
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ConfusionLevel represents the severity of a dependency confusion.
+type ConfusionLevel uint
+
+const (
+	// The package name resolves on several registries
+	ConfusionWarning ConfusionLevel = iota + 1
+
+	// The package name resolves on a public registry,
+	// with a version higher than on the private registries
+	ConfusionCritical
+)
+
+func (l ConfusionLevel) String() string {
+	switch l {
+	case ConfusionWarning:
+		return "warning"
+
+	case ConfusionCritical:
+		return "critical"
+	}
+
+	return "<none>"
+}
+
+// RegistryVersion represents the latest version of a package on a registry.
+type RegistryVersion struct {
+	// Name of the registry (e.g. `pypi`)
+	Registry string
+
+	// Latest version of the package on this registry
+	Version string
+
+	// Whether the registry is public (e.g. PyPI)
+	Public bool
+}
+
+// DependencyConfusion represents a package name resolving on several registries,
+// which can be exploited to substitute a private package with a public one.
+type DependencyConfusion struct {
+	Level ConfusionLevel
+
+	// Registries where the package has been found
+	Registries []RegistryVersion
+}
+
+// IsCritical returns true if the confusion is not nil and critical.
+func (c *DependencyConfusion) IsCritical() bool {
+	return c != nil && c.Level == ConfusionCritical
+}
+
+// String returns the registries where the package has been found,
+// with their latest versions (e.g. `gitlab 1.0.0, pypi 2.0.0`).
+func (c DependencyConfusion) String() string {
+	found := make([]string, len(c.Registries))
+
+	for i, r := range c.Registries {
+		found[i] = fmt.Sprintf("%s %s", r.Registry, strings.TrimPrefix(r.Version, "v"))
+	}
+
+	return strings.Join(found, ", ")
+}
+
+// ConfusionDetector is a checker decorating another one,
+// so that each package is also looked up on all the public and private registries,
+// to detect the dependency confusions (see DependencyConfusion).
+type ConfusionDetector struct {
+	// Checker for the updates
+	Checker Checker
+
+	// Checkers for the public registries (e.g. PyPI)
+	Public []Checker
+
+	// Checkers for the private registries (e.g. Gitlab)
+	Private []Checker
+}
+
+// RequiredUpdate returns the result of the decorated checker,
+// with the dependency confusion (if any) found for the package.
+//
+// The confusion is critical if the latest version on a public registry
+// is higher than all the versions on the private ones.
+// A registry which cannot be queried is ignored (with a warning).
+func (d ConfusionDetector) RequiredUpdate(
+	pkg string,
+	requirement VersionRequirement,
+) (CheckResult, error) {
+	result, err := d.Checker.RequiredUpdate(pkg, requirement)
+
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	var found []RegistryVersion
+
+	lookup := func(checkers []Checker, public bool) {
+		for _, checker := range checkers {
+			r, err := checker.RequiredUpdate(pkg, requirement)
+
+			if err != nil {
+				log.Warnf("Cannot look up %s for dependency confusion: %s", pkg, err.Error())
+
+				continue
+			}
+
+			if r.LatestVersion != "" {
+				found = append(found, RegistryVersion{
+					Registry: r.Registry,
+					Version:  r.LatestVersion,
+					Public:   public,
+				})
+			}
+		}
+	}
+
+	lookup(d.Private, false)
+	lookup(d.Public, true)
+
+	if len(found) < 2 {
+		return result, nil
+	}
+
+	result.Confusion = &DependencyConfusion{
+		Level:      confusionLevel(found),
+		Registries: found,
+	}
+
+	return result, nil
+}
+
+// confusionLevel returns ConfusionCritical if a public version
+// is higher than all the private ones, otherwise ConfusionWarning.
+func confusionLevel(found []RegistryVersion) ConfusionLevel {
+	var public, private *Version
+
+	for _, r := range found {
+		v, err := ParseVersion(r.Version)
+
+		if err != nil {
+			log.Debugf("Ignoring invalid version from %s: %s", r.Registry, r.Version)
+
+			continue
+		}
+
+		max := &private
+
+		if r.Public {
+			max = &public
+		}
+
+		if *max == nil || (*max).Compare(v) < 0 {
+			*max = &v
+		}
+	}
+
+	if public != nil && private != nil && public.Compare(*private) > 0 {
+		return ConfusionCritical
+	}
+
+	return ConfusionWarning
+}
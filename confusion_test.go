@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConfusionDetector(t *testing.T) {
+	pypi := registryChecker("pypi", map[string]string{"requests": "v2.31.0", "shared": "v1.0.0", "hijacked": "v99.0.0"})
+
+	gitlab := registryChecker("gitlab", map[string]string{"internal": "v1.2.0", "shared": "v1.1.0", "hijacked": "v1.0.0"})
+
+	detector := ConfusionDetector{
+		Checker: CompositeChecker{pypi, gitlab},
+		Public:  []Checker{pypi},
+		Private: []Checker{gitlab, failingChecker(errors.New("unavailable"))},
+	}
+
+	tests := []struct {
+		pkg      string
+		expected *DependencyConfusion
+	}{
+		{"requests", nil},
+		{"internal", nil},
+		{"unknown", nil},
+		{
+			"shared",
+			&DependencyConfusion{
+				Level: ConfusionWarning,
+				Registries: []RegistryVersion{
+					{Registry: "gitlab", Version: "v1.1.0"},
+					{Registry: "pypi", Version: "v1.0.0", Public: true},
+				},
+			},
+		},
+		{
+			"hijacked",
+			&DependencyConfusion{
+				Level: ConfusionCritical,
+				Registries: []RegistryVersion{
+					{Registry: "gitlab", Version: "v1.0.0"},
+					{Registry: "pypi", Version: "v99.0.0", Public: true},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		result, err := detector.RequiredUpdate(test.pkg, VersionRequirement{{"==", "v1.0.0"}})
+
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", test.pkg, err.Error())
+		}
+
+		if !reflect.DeepEqual(result.Confusion, test.expected) {
+			t.Errorf("Expected confusion %v for %s, but got %v", test.expected, test.pkg, result.Confusion)
+		}
+	}
+
+	// The error of the decorated checker is returned
+	detector.Checker = failingChecker(errors.New("failure"))
+
+	if _, err := detector.RequiredUpdate("shared", VersionRequirement{}); err == nil {
+		t.Errorf("Expected error from the decorated checker")
+	}
+}
+
+func TestDependencyConfusionString(t *testing.T) {
+	confusion := DependencyConfusion{
+		Level: ConfusionCritical,
+		Registries: []RegistryVersion{
+			{Registry: "gitlab", Version: "v1.0.0"},
+			{Registry: "pypi", Version: "v99.0.0", Public: true},
+		},
+	}
+
+	if s := confusion.String(); s != "gitlab 1.0.0, pypi 99.0.0" {
+		t.Errorf("Unexpected representation: %s", s)
+	}
+
+	if s := confusion.Level.String(); s != "critical" {
+		t.Errorf("Unexpected level: %s", s)
+	}
+
+	var none *DependencyConfusion
+
+	if none.IsCritical() || !confusion.IsCritical() {
+		t.Errorf("Unexpected criticality")
+	}
+}
+
+func TestCheckUpdatesDependencyConfusion(t *testing.T) {
+	pypi := registryChecker("pypi", map[string]string{"shared": "v1.0.0", "hijacked": "v99.0.0"})
+
+	gitlab := registryChecker("gitlab", map[string]string{"shared": "v1.1.0", "hijacked": "v1.0.0"})
+
+	updates, err := CheckUpdates(
+		Dependencies{
+			"shared":   VersionRequirement{{"==", "v1.1.0"}},
+			"hijacked": VersionRequirement{{"==", "v1.0.0"}},
+		},
+		RunDependency,
+		LockedVersions{},
+		FailPolicy{UpdateLevel: Major},
+		2,
+		ConfusionDetector{Checker: gitlab, Public: []Checker{pypi}, Private: []Checker{gitlab}},
+	)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(updates) != 2 {
+		t.Fatalf("Expected both packages to be reported: %v", updates)
+	}
+
+	// Sorted by name
+	if u := updates[0]; u.PackageName != "hijacked" || !u.Confusion.IsCritical() || !u.Fatal {
+		t.Errorf("Expected a fatal critical confusion for hijacked: %+v", u)
+	}
+
+	if u := updates[1]; u.PackageName != "shared" || u.Confusion == nil || u.Confusion.IsCritical() || u.Fatal {
+		t.Errorf("Expected a non-fatal confusion for shared: %+v", u)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "os"
+
+// ExitCode is the status with which wilf exits,
+// stable so the CI can tell an outdated dependency file from a failure.
+// A crash (unrecovered panic) exits with `2` too (as ExitConfigError),
+// but the Go runtime prints `panic:` and the stack trace on stderr.
+type ExitCode int
+
+const (
+	// No update failing the check (see FailPolicy)
+	ExitOk ExitCode = 0
+
+	// Invalid command line arguments
+	ExitUsageError ExitCode = 1
+
+	// Invalid configuration (e.g. configuration file, HTTP settings,
+	// or registry unknown for a mapping or a package index)
+	ExitConfigError ExitCode = 2
+
+	// Dependency file which cannot be read
+	ExitReadError ExitCode = 3
+
+	// Dependency file (or its lock) which cannot be parsed
+	ExitParseError ExitCode = 4
+
+	// Some updates fail the check (see FailPolicy)
+	ExitOutdated ExitCode = 5
+
+	// Some packages cannot be checked (e.g. registry unavailable)
+	ExitCheckError ExitCode = 6
+)
+
+// exit terminates the program with the given code.
+func exit(code ExitCode) {
+	os.Exit(int(code))
+}
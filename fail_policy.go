@@ -0,0 +1,63 @@
+package main
+
+// FailPolicy indicates which of the reported packages fail the check
+// (see PackageUpdate.Fatal), so the exit code is ExitOutdated.
+type FailPolicy struct {
+	// Minimum level of the updates failing the check
+	UpdateLevel UpdateLevel
+
+	// Kinds of the dependencies whose updates fail the check
+	// (e.g. `runtime`, `dev` or the name of a group), all if empty
+	Kinds []string
+
+	// Whether a package unknown to all the registries fails the check
+	NotFound bool
+
+	// Whether the packages are only reported as warnings, none failing the check
+	WarningsOnly bool
+}
+
+// FailPolicy returns the policy according the settings
+// (`update_level`, `fail_on_kinds`, `fail_on_not_found` and `warnings_only`).
+func (s Settings) FailPolicy() FailPolicy {
+	return FailPolicy{
+		UpdateLevel:  s.UpdateLevel,
+		Kinds:        s.FailOnKinds,
+		NotFound:     s.FailOnNotFound,
+		WarningsOnly: s.WarningsOnly,
+	}
+}
+
+// IsFatal checks whether the update fails the check according the policy:
+// an update of at least the policy level, a critical dependency confusion,
+// or a package not found if configured so, for one of the policy kinds;
+// never in the warnings-only mode.
+func (p FailPolicy) IsFatal(update PackageUpdate) bool {
+	if p.WarningsOnly || update.Error != nil || !p.failsOnKind(update.DependencyKind) {
+		return false
+	}
+
+	if update.NotFound {
+		return p.NotFound
+	}
+
+	lvl := update.UpdateLevel
+
+	return (lvl > 0 && lvl >= p.UpdateLevel) || update.Confusion.IsCritical()
+}
+
+// failsOnKind checks whether the updates of the dependencies
+// of the given kind can fail the check.
+func (p FailPolicy) failsOnKind(kind DependencyKind) bool {
+	if len(p.Kinds) == 0 {
+		return true
+	}
+
+	for _, k := range p.Kinds {
+		if GroupDependency(k) == kind {
+			return true
+		}
+	}
+
+	return false
+}
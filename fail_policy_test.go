@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFailPolicyIsFatal(t *testing.T) {
+	critical := &DependencyConfusion{Level: ConfusionCritical}
+
+	tests := []struct {
+		policy   FailPolicy
+		update   PackageUpdate
+		expected bool
+	}{
+		{FailPolicy{UpdateLevel: Minor}, PackageUpdate{UpdateLevel: Major, DependencyKind: DevDependency}, true},
+		{FailPolicy{UpdateLevel: Minor}, PackageUpdate{UpdateLevel: Patch, DependencyKind: RunDependency}, false},
+		{FailPolicy{UpdateLevel: Minor}, PackageUpdate{DependencyKind: RunDependency}, false},
+		{FailPolicy{UpdateLevel: Major}, PackageUpdate{DependencyKind: RunDependency, Confusion: critical}, true},
+		{FailPolicy{UpdateLevel: Minor}, PackageUpdate{DependencyKind: RunDependency, NotFound: true}, false},
+		{FailPolicy{NotFound: true}, PackageUpdate{DependencyKind: RunDependency, NotFound: true}, true},
+		{FailPolicy{UpdateLevel: Patch}, PackageUpdate{UpdateLevel: Major, Error: errors.New("failure")}, false},
+
+		// Only major runtime updates
+		{FailPolicy{UpdateLevel: Major, Kinds: []string{"runtime"}}, PackageUpdate{UpdateLevel: Major, DependencyKind: RunDependency}, true},
+		{FailPolicy{UpdateLevel: Major, Kinds: []string{"runtime"}}, PackageUpdate{UpdateLevel: Major, DependencyKind: DevDependency}, false},
+		{FailPolicy{UpdateLevel: Major, Kinds: []string{"Docs_Group"}}, PackageUpdate{UpdateLevel: Major, DependencyKind: GroupDependency("docs-group")}, true},
+
+		// Warnings-only mode
+		{FailPolicy{UpdateLevel: Patch, WarningsOnly: true}, PackageUpdate{UpdateLevel: Major, DependencyKind: RunDependency}, false},
+		{FailPolicy{NotFound: true, WarningsOnly: true}, PackageUpdate{DependencyKind: RunDependency, Confusion: critical}, false},
+	}
+
+	for i, test := range tests {
+		if fatal := test.policy.IsFatal(test.update); fatal != test.expected {
+			t.Errorf("#%d: Expected fatal = %v with %+v for %+v", i, test.expected, test.policy, test.update)
+		}
+	}
+}
+
+func TestSettingsFailPolicy(t *testing.T) {
+	settings := DefaultSettings()
+
+	settings.FailOnKinds = []string{"runtime"}
+	settings.FailOnNotFound = true
+
+	expected := FailPolicy{UpdateLevel: Minor, Kinds: []string{"runtime"}, NotFound: true}
+
+	if policy := settings.FailPolicy(); !reflect.DeepEqual(policy, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, policy)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// FileFormat represents the format of a file declaring dependencies.
+type FileFormat int
+
+const (
+	PipfileFormat FileFormat = iota
+	RequirementsFormat
+	PyprojectFormat
+)
+
+func (f FileFormat) String() string {
+	if f == RequirementsFormat {
+		return "requirements"
+	} else if f == PyprojectFormat {
+		return "pyproject"
+	}
+
+	return "Pipfile"
+}
+
+// DetectFileFormat detects the format of a dependency file according its name:
+// either a `pyproject.toml`, a pip requirements/constraints file
+// (e.g. `requirements-dev.txt`), or a Pipfile (by default).
+func DetectFileFormat(path string) FileFormat {
+	name := strings.ToLower(filepath.Base(path))
+
+	if strings.HasSuffix(name, "pyproject.toml") {
+		return PyprojectFormat
+	}
+
+	if strings.HasSuffix(name, ".txt") ||
+		strings.HasSuffix(name, ".in") ||
+		strings.Contains(name, "requirements") ||
+		strings.Contains(name, "constraints") {
+		return RequirementsFormat
+	}
+
+	return PipfileFormat
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDetectFileFormat(t *testing.T) {
+	tests := map[string]FileFormat{
+		"Pipfile":                       PipfileFormat,
+		"/path/to/Pipfile":              PipfileFormat,
+		"resources/valid1.pipfile":      PipfileFormat,
+		"requirements.txt":              RequirementsFormat,
+		"/path/to/requirements-dev.txt": RequirementsFormat,
+		"constraints.txt":               RequirementsFormat,
+		"requirements.in":               RequirementsFormat,
+		"deps.txt":                      RequirementsFormat,
+		"pyproject.toml":                PyprojectFormat,
+		"/path/to/pyproject.toml":       PyprojectFormat,
+	}
+
+	for path, expected := range tests {
+		if result := DetectFileFormat(path); result != expected {
+			t.Errorf("Expected format for '%s': %s, got: %s", path, expected, result)
+		}
+	}
+}
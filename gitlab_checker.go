@@ -1,35 +1,86 @@
 package main
 
+import (
+	"net/http"
+)
+
 // GitlabChecker represents a struct that holds the configuration for a GitLab registry.
 type GitlabChecker struct {
 	Config GitlabRegistryConfig
+
+	// Policy about the pre-releases as candidate versions
+	Prereleases PrereleasePolicy
+
+	// HTTP client to query the registry (the default one if nil)
+	Client *http.Client
 }
 
-// RequiredUpdate checks if a package requires an update and returns the current version,
+// RequiredUpdate checks if a package requires an update and returns the latest version,
 // update level, home URL and error (if any).
 func (c GitlabChecker) RequiredUpdate(
 	pkg string,
 	requirement VersionRequirement,
-) (string, UpdateLevel, string, error) {
-	info, err := GetGitlabProjectInfo(c.Config, pkg)
+) (CheckResult, error) {
+	info, err := GetGitlabProjectInfo(c.Client, c.Config, pkg)
 
 	if err != nil {
-		return "", 0, "", err
+		return CheckResult{}, err
 	}
 
 	if info == nil {
-		return "", 0, "", nil
+		return CheckResult{NotFound: true}, nil
+	}
+
+	return c.checkProject(*info, requirement)
+}
+
+// checkProject checks the project information against the requirement,
+// considering the latest of the released versions
+// (only including the pre-releases according the policy),
+// whose page is reported as package URL.
+func (c GitlabChecker) checkProject(
+	info ProjectInfo,
+	requirement VersionRequirement,
+) (CheckResult, error) {
+	latest := info.Version
+	packageUrl := info.HomeURL
+
+	if len(info.Releases) > 0 {
+		allowPrereleases := c.Prereleases.Allows(requirement)
+
+		release := LatestRelease(info.Releases, func(_ ReleaseInfo, v Version) bool {
+			return allowPrereleases || !v.IsPreRelease()
+		})
+
+		if release == nil {
+			return CheckResult{}, nil
+		}
+
+		latest = release.Version
+
+		if release.Url != "" {
+			packageUrl = release.Url
+		}
+	}
+
+	result := CheckResult{
+		LatestVersion:      latest,
+		InstallableVersion: latest,
+		PackageUrl:         packageUrl,
+		Registry:           c.Config.RegistryName(),
 	}
 
-	if !ShouldUpdate(requirement, info.Version) {
-		return info.Version, 0, info.HomeURL, nil
+	if !ShouldUpdate(requirement, latest) {
+		return result, nil
 	}
 
-	lvl, err := CreateUpdateLevel(requirement, info.Version)
+	lvl, err := CreateUpdateLevel(requirement, latest)
 
 	if err != nil {
-		return "", 0, "", err
+		return CheckResult{}, err
 	}
 
-	return info.Version, lvl, info.HomeURL, nil
+	result.UpdateLevel = lvl
+
+	return result, nil
 }
@@ -18,7 +18,8 @@ func TestGitlabChecker(t *testing.T) {
 	expectedVersion := "v1.0.19"
 	expectedLevel := Major
 	expectedUrl := "https://gitlab.com/gitlab-org/secure/tools/gitlab-bot-hall-monitor/-/packages/11705498"
-	version, level, url, err := checker.RequiredUpdate(pkg, req)
+	result, err := checker.RequiredUpdate(pkg, req)
+	version, level, url := result.LatestVersion, result.UpdateLevel, result.PackageUrl
 
 	if err != nil {
 		t.Errorf("Expected no error, but got %v", err)
@@ -47,7 +48,8 @@ func TestGitlabCheckerNonExisting(t *testing.T) {
 	req := VersionRequirement{
 		VersionConstraint{">=", "v1.0.0"},
 	}
-	version, level, url, err := checker.RequiredUpdate(pkg, req)
+	result, err := checker.RequiredUpdate(pkg, req)
+	version, level, url := result.LatestVersion, result.UpdateLevel, result.PackageUrl
 
 	if err != nil {
 		t.Errorf("Expected no error, but got %v", err)
@@ -65,3 +67,56 @@ func TestGitlabCheckerNonExisting(t *testing.T) {
 		t.Errorf("Expected level 0, but got %v", level)
 	}
 }
+
+func TestGitlabCheckProject(t *testing.T) {
+	info := ProjectInfo{
+		Name:    "lorem",
+		Version: "v2.0.0rc1",
+		HomeURL: "https://gitlab/lorem",
+		Releases: []ReleaseInfo{
+			{Version: "v1.0.0", Url: "https://gitlab/lorem/-/packages/1"},
+			{Version: "v1.2.0", Url: "https://gitlab/lorem/-/packages/3"},
+			{Version: "v1.1.0", Url: "https://gitlab/lorem/-/packages/2"},
+			{Version: "v2.0.0rc1", Url: "https://gitlab/lorem/-/packages/4"},
+		},
+	}
+
+	tests := []struct {
+		policy         PrereleasePolicy
+		expectedLatest string
+		expectedLevel  UpdateLevel
+		expectedUrl    string
+	}{
+		{PrereleaseNever, "v1.2.0", Minor, "https://gitlab/lorem/-/packages/3"},
+		{PrereleaseIfCurrent, "v1.2.0", Minor, "https://gitlab/lorem/-/packages/3"},
+		{PrereleaseAlways, "v2.0.0rc1", Major, "https://gitlab/lorem/-/packages/4"},
+	}
+
+	for _, test := range tests {
+		checker := GitlabChecker{Prereleases: test.policy}
+
+		result, err := checker.checkProject(info, VersionRequirement{{"==", "v1.0.0"}})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			continue
+		}
+
+		if result.LatestVersion != test.expectedLatest ||
+			result.InstallableVersion != test.expectedLatest {
+			t.Errorf("For policy %s, expected latest version %s, but got %+v",
+				test.policy, test.expectedLatest, result)
+		}
+
+		if result.UpdateLevel != test.expectedLevel {
+			t.Errorf("For policy %s, expected level %s, but got %s",
+				test.policy, test.expectedLevel, result.UpdateLevel)
+		}
+
+		// The URL of the reported version, not of the highest one
+		if result.PackageUrl != test.expectedUrl {
+			t.Errorf("For policy %s, expected url %s, but got %s",
+				test.policy, test.expectedUrl, result.PackageUrl)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GitlabCIEnvironment represents the predefined variables of a Gitlab CI job,
+// used to configure the Gitlab registry of the project automatically.
+type GitlabCIEnvironment struct {
+	// Root URL of the Gitlab API (`CI_API_V4_URL`)
+	ApiUrl string
+
+	// Identifier of the project (`CI_PROJECT_ID`)
+	ProjectId string
+
+	// Token of the job (`CI_JOB_TOKEN`)
+	JobToken string
+}
+
+// LookupGitlabCIEnvironment returns the Gitlab CI environment
+// from the predefined variables `CI_API_V4_URL`, `CI_PROJECT_ID` and `CI_JOB_TOKEN`,
+// or nil if not running in a Gitlab CI job.
+func LookupGitlabCIEnvironment() *GitlabCIEnvironment {
+	env := GitlabCIEnvironment{
+		ApiUrl:    strings.TrimSuffix(os.Getenv("CI_API_V4_URL"), "/"),
+		ProjectId: os.Getenv("CI_PROJECT_ID"),
+		JobToken:  os.Getenv("CI_JOB_TOKEN"),
+	}
+
+	if env.ApiUrl == "" || env.ProjectId == "" || env.JobToken == "" {
+		return nil
+	}
+
+	return &env
+}
+
+// RegistryConfig returns the configuration of the registry of the project,
+// authenticated using the job token.
+func (e GitlabCIEnvironment) RegistryConfig() GitlabRegistryConfig {
+	return GitlabRegistryConfig{
+		ProjectApiPackagesUrl: fmt.Sprintf("%s/projects/%s/packages", e.ApiUrl, e.ProjectId),
+		JobToken:              e.JobToken,
+		FromCI:                true,
+	}
+}
+
+// Configure returns the Gitlab registries completed according the environment:
+// the registry of the project if none is configured, otherwise the given ones
+// with the job token for those of the same Gitlab instance without credentials.
+func (e GitlabCIEnvironment) Configure(configs []GitlabRegistryConfig) []GitlabRegistryConfig {
+	if len(configs) == 0 {
+		return []GitlabRegistryConfig{e.RegistryConfig()}
+	}
+
+	completed := make([]GitlabRegistryConfig, len(configs))
+
+	for i, config := range configs {
+		if !config.HasCredentials() && strings.HasPrefix(config.PackagesUrl(), e.ApiUrl+"/") {
+			config.JobToken = e.JobToken
+			config.FromCI = true
+		}
+
+		completed[i] = config
+	}
+
+	return completed
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupGitlabCIEnvironment(t *testing.T) {
+	t.Setenv("CI_API_V4_URL", "https://gitlab.example.com/api/v4/")
+	t.Setenv("CI_PROJECT_ID", "42")
+	t.Setenv("CI_JOB_TOKEN", "")
+
+	if env := LookupGitlabCIEnvironment(); env != nil {
+		t.Errorf("Expected no Gitlab CI environment without job token, but got %+v", env)
+	}
+
+	t.Setenv("CI_JOB_TOKEN", "job-secret")
+
+	env := LookupGitlabCIEnvironment()
+
+	expected := &GitlabCIEnvironment{
+		ApiUrl:    "https://gitlab.example.com/api/v4",
+		ProjectId: "42",
+		JobToken:  "job-secret",
+	}
+
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, env)
+	}
+}
+
+func TestGitlabCIEnvironmentConfigure(t *testing.T) {
+	env := GitlabCIEnvironment{
+		ApiUrl:    "https://gitlab.example.com/api/v4",
+		ProjectId: "42",
+		JobToken:  "job-secret",
+	}
+
+	tests := []struct {
+		configs  []GitlabRegistryConfig
+		expected []GitlabRegistryConfig
+	}{
+		{
+			configs: nil,
+			expected: []GitlabRegistryConfig{{
+				ProjectApiPackagesUrl: "https://gitlab.example.com/api/v4/projects/42/packages",
+				JobToken:              "job-secret",
+				FromCI:                true,
+			}},
+		},
+		{
+			configs: []GitlabRegistryConfig{
+				{GroupApiPackagesUrl: "https://gitlab.example.com/api/v4/groups/7/packages"},
+				{ProjectApiPackagesUrl: "https://gitlab.example.com/api/v4/projects/8/packages", PrivateToken: "token"},
+				{ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/9/packages"},
+			},
+			expected: []GitlabRegistryConfig{
+				{GroupApiPackagesUrl: "https://gitlab.example.com/api/v4/groups/7/packages", JobToken: "job-secret", FromCI: true},
+				{ProjectApiPackagesUrl: "https://gitlab.example.com/api/v4/projects/8/packages", PrivateToken: "token"},
+				{ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/9/packages"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if configs := env.Configure(test.configs); !reflect.DeepEqual(configs, test.expected) {
+			t.Errorf("Expected %+v, but got %+v", test.expected, configs)
+		}
+	}
+}
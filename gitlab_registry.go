@@ -6,18 +6,44 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	log "github.com/sirupsen/logrus"
 )
 
 // GitlabRegistryConfig represents the configuration
-// for accessing Gitlab's registry API.
+// for accessing Gitlab's registry API,
+// either for the packages of a project or of a group.
 type GitlabRegistryConfig struct {
+	// Name of the registry, reported as registry (`gitlab` if empty)
+	Name string `toml:"name"`
+
+	// URL of the packages API for a project (`/projects/:id/packages`)
 	ProjectApiPackagesUrl string `toml:"project_api_packages_url"`
-	PrivateToken          string `toml:"private_token"`
+
+	// URL of the packages API for a group (`/groups/:id/packages`),
+	// including the packages of its projects and subgroups
+	GroupApiPackagesUrl string `toml:"group_api_packages_url"`
+
+	// Credentials: either a personal or project access token (`PRIVATE-TOKEN` header),
+	// a CI job token (`JOB-TOKEN` header, see GitlabCIEnvironment),
+	// or a deploy token (basic authentication)
+	PrivateToken        string `toml:"private_token"`
+	JobToken            string `toml:"job_token"`
+	DeployTokenUsername string `toml:"deploy_token_username"`
+	DeployToken         string `toml:"deploy_token"`
+
+	// Whether the job token is set from the Gitlab CI environment (see GitlabCIEnvironment):
+	// as the registry may not be available to the job (e.g. package registry disabled,
+	// or project not allowed for the job token), its packages are then considered as not found
+	FromCI bool `toml:"-"`
 }
 
+// Number of packages per page requested to the Gitlab API (maximum allowed by Gitlab)
+const gitlabPageSize = 100
+
 type gitlabProjectLinks struct {
 	WebPath string `json:"web_path"`
 }
@@ -28,67 +54,249 @@ type gitlabProjectInfo struct {
 	Links   gitlabProjectLinks `json:"_links"`
 }
 
+// PackagesUrl returns the URL of the packages API, either for the project or the group.
+func (c GitlabRegistryConfig) PackagesUrl() string {
+	if c.ProjectApiPackagesUrl != "" {
+		return c.ProjectApiPackagesUrl
+	}
+
+	return c.GroupApiPackagesUrl
+}
+
+// HasCredentials checks whether a token is configured for the registry.
+func (c GitlabRegistryConfig) HasCredentials() bool {
+	return c.PrivateToken != "" || c.JobToken != "" || c.DeployToken != ""
+}
+
+// authenticate sets the credentials of the registry (if any) on the request.
+func (c GitlabRegistryConfig) authenticate(request *http.Request) {
+	switch {
+	case c.PrivateToken != "":
+		request.Header.Set("PRIVATE-TOKEN", c.PrivateToken)
+
+	case c.JobToken != "":
+		request.Header.Set("JOB-TOKEN", c.JobToken)
+
+	case c.DeployToken != "":
+		request.SetBasicAuth(c.DeployTokenUsername, c.DeployToken)
+	}
+}
+
+// RegistryName returns the name of the registry (`gitlab` if not named).
+func (c GitlabRegistryConfig) RegistryName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return "gitlab"
+}
+
 // LoadGitlabRegistryConfig loads the Gitlab registry configuration from a TOML file.
 // It takes a file path as input and returns a pointer to a GitlabRegistryConfig struct and an error.
+// If several registries are configured (see LoadGitlabRegistryConfigs), the first one is returned.
 func LoadGitlabRegistryConfig(path string) (*GitlabRegistryConfig, error) {
+	configs, err := LoadGitlabRegistryConfigs(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configs) == 0 {
+		return &GitlabRegistryConfig{}, nil
+	}
+
+	return &configs[0], nil
+}
+
+// LoadGitlabRegistryConfigs loads the configurations of the Gitlab registries from a TOML file,
+// either a single `[gitlab]` table or several `[[gitlab]]` ones.
+// Each registry must have either a project or a group packages URL.
+func LoadGitlabRegistryConfigs(path string) ([]GitlabRegistryConfig, error) {
 	var config struct {
-		Gitlab GitlabRegistryConfig `toml:"gitlab"`
+		Gitlab toml.Primitive `toml:"gitlab"`
 	}
 
-	_, err := toml.DecodeFile(path, &config)
+	md, err := toml.DecodeFile(path, &config)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &config.Gitlab, nil
+	var configs []GitlabRegistryConfig
+
+	switch md.Type("gitlab") {
+	case "":
+		return nil, nil
+
+	case "ArrayHash":
+		err = md.PrimitiveDecode(config.Gitlab, &configs)
+
+	default:
+		configs = make([]GitlabRegistryConfig, 1)
+		err = md.PrimitiveDecode(config.Gitlab, &configs[0])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range configs {
+		if (c.ProjectApiPackagesUrl == "") == (c.GroupApiPackagesUrl == "") {
+			return nil, fmt.Errorf(
+				"either project_api_packages_url or group_api_packages_url must be configured for Gitlab registry '%s'",
+				c.RegistryName())
+		}
+	}
+
+	return configs, nil
 }
 
 // GetGitlabProjectInfo retrieves information about a project from Gitlab's registry API.
-// It takes a GitlabRegistryConfig struct and a package name as input.
+// It takes an HTTP client (the default one if nil),
+// a GitlabRegistryConfig struct and a package name as input.
 // It returns a pointer to a ProjectInfo struct and an error.
 // If the package is not found, it returns nil and no error.
-// If the package is found but there are multiple projects with the same name,
-// it returns an error.
 // If there is an error while retrieving the package information,
 // it returns an error.
+//
+// All the pages of the packages API are read, and only the packages
+// with the same normalized name are considered (the Gitlab filter being fuzzy).
+// The version of the project is the highest one according PEP 440
+// (as the packages are not ordered by version).
 func GetGitlabProjectInfo(
+	client *http.Client,
 	gitlabConfig GitlabRegistryConfig,
 	packageName string,
 ) (*ProjectInfo, error) {
-	url := fmt.Sprintf(
-		"%s?package_type=pypi&package_name=%s",
-		gitlabConfig.ProjectApiPackagesUrl,
-		packageName,
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	name := NormalizePackageName(packageName)
+
+	var packages []gitlabProjectInfo
+
+	for page := "1"; page != ""; {
+		pageUrl := fmt.Sprintf(
+			"%s?package_type=pypi&package_name=%s&per_page=%d&page=%s",
+			gitlabConfig.PackagesUrl(),
+			url.QueryEscape(packageName),
+			gitlabPageSize,
+			url.QueryEscape(page),
+		)
+
+		found, next, err := getGitlabPackages(client, gitlabConfig, pageUrl)
+
+		if err != nil || found == nil {
+			return nil, err
+		}
+
+		for _, pkg := range found {
+			if NormalizePackageName(pkg.Name) == name {
+				packages = append(packages, pkg)
+			}
+		}
+
+		if next == page {
+			break
+		}
+
+		page = next
+	}
+
+	if len(packages) == 0 {
+		return nil, nil
+	}
+
+	// ---
+
+	releases := make([]ReleaseInfo, 0, len(packages))
+
+	for _, pkg := range packages {
+		releases = append(releases, ReleaseInfo{
+			Version: fmt.Sprintf("v%s", pkg.Version),
+			Url:     gitlabPackageUrl(gitlabConfig, pkg),
+		})
+	}
+
+	latest := LatestRelease(releases, func(ReleaseInfo, Version) bool { return true })
+
+	if latest == nil {
+		return nil, fmt.Errorf("no valid version for package %s in Gitlab registry '%s'",
+			packageName, gitlabConfig.RegistryName())
+	}
+
+	var info gitlabProjectInfo
+
+	for i, release := range releases {
+		if release.Version == latest.Version {
+			info = packages[i]
+		}
+	}
+
+	return &ProjectInfo{
+		Name:     info.Name,
+		Version:  latest.Version,
+		Summary:  "",
+		HomeURL:  latest.Url,
+		Releases: releases,
+	}, nil
+}
+
+// gitlabPackageUrl returns the URL of the web page of a Gitlab package,
+// on the host of the registry.
+func gitlabPackageUrl(gitlabConfig GitlabRegistryConfig, pkg gitlabProjectInfo) string {
+	urlParts := strings.SplitAfterN(gitlabConfig.PackagesUrl(), "/", 4)
+
+	return fmt.Sprintf("%s%s",
+		strings.Join(urlParts[0:3], ""),
+		strings.TrimPrefix(pkg.Links.WebPath, "/"),
 	)
+}
 
+// getGitlabPackages returns a page of packages from Gitlab's registry API,
+// and the number of the next page (empty if it's the last one).
+// If the project or group is not found, or the registry configured from the CI
+// is not available to the job, it returns nil and no error.
+func getGitlabPackages(
+	client *http.Client,
+	gitlabConfig GitlabRegistryConfig,
+	url string,
+) ([]gitlabProjectInfo, string, error) {
 	// Create a new HTTP request with the Gitlab API URL
 	request, err := http.NewRequest("GET", url, nil)
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	// Set the Gitlab API token in the request header
-	if gitlabConfig.PrivateToken != "" {
-		request.Header.Set("PRIVATE-TOKEN", gitlabConfig.PrivateToken)
-	}
+	// Set the Gitlab API credentials in the request
+	gitlabConfig.authenticate(request)
 
-	// Send the HTTP request
-	client := &http.Client{}
 	response, err := client.Do(request)
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	defer response.Body.Close()
 
+	if err := checkTransientStatus(url, response); err != nil {
+		return nil, "", err
+	}
+
+	if gitlabConfig.FromCI && isUnavailableStatus(response.StatusCode) {
+		log.Warnf("Gitlab registry '%s' not available to the CI job (%s), packages considered as not found: %s",
+			gitlabConfig.RegistryName(), response.Status, redactedUrl(url))
+
+		return nil, "", nil
+	}
+
 	// Read the response body
 	body, err := io.ReadAll(response.Body)
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// Unmarshal the JSON response into a slice of ProjectInfo structs
@@ -104,11 +312,11 @@ func GetGitlabProjectInfo(
 		err2 := json.Unmarshal(body, &jsonResp)
 
 		if err2 != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		if err2 == nil && jsonResp.Message == "Not Found" {
-			return nil, nil
+			return nil, "", nil
 		}
 
 		errMsg := err.Error()
@@ -117,39 +325,20 @@ func GetGitlabProjectInfo(
 			errMsg = jsonResp.Message
 		}
 
-		return nil, errors.New(fmt.Sprintf("Project information not found in the JSON response: %s", errMsg))
+		return nil, "", errors.New(fmt.Sprintf("Project information not found in the JSON response: %s", errMsg))
 	}
 
-	// ---
-
-	// Check if the response is empty
-	l := len(projectInfo)
-
-	if l == 0 {
-		return nil, nil
-	}
-
-	/* TODO? Check if the response contains more than one element
-	if len(projectInfo) > 1 {
-		Warn? The Gitlab API indicates more than one project with the same name
+	if projectInfo == nil {
+		projectInfo = []gitlabProjectInfo{}
 	}
-	*/
-
-	// ---
 
-	info := projectInfo[l-1]
-
-	urlParts := strings.SplitAfterN(gitlabConfig.ProjectApiPackagesUrl, "/", 4)
-	homeUrl := fmt.Sprintf("%s%s",
-		strings.Join(urlParts[0:3], ""),
-		strings.TrimPrefix(info.Links.WebPath, "/"),
-	)
+	return projectInfo, response.Header.Get("X-Next-Page"), nil
+}
 
-	// Return the first element of the slice
-	return &ProjectInfo{
-		Name:    info.Name,
-		Version: fmt.Sprintf("v%s", info.Version),
-		Summary: "",
-		HomeURL: homeUrl,
-	}, nil
+// isUnavailableStatus checks whether the HTTP status indicates
+// that the registry cannot be accessed (`401`, `403` or `404`).
+func isUnavailableStatus(code int) bool {
+	return code == http.StatusUnauthorized ||
+		code == http.StatusForbidden ||
+		code == http.StatusNotFound
 }
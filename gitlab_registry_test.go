@@ -1,6 +1,13 @@
 package main
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"testing"
 
 	"golang.org/x/mod/semver"
@@ -16,7 +23,7 @@ func TestGetPromptlibProjectInfo(t *testing.T) {
 
 	// Test a package that exists in the project
 	expectedPkg := "gitlab-bot-hall-monitor"
-	projectInfo, err := GetGitlabProjectInfo(config, expectedPkg)
+	projectInfo, err := GetGitlabProjectInfo(nil, config, expectedPkg)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -36,7 +43,7 @@ func TestGetPromptlibProjectInfo(t *testing.T) {
 	}
 
 	// Test a package that does not exist in the project
-	projectInfo, err = GetGitlabProjectInfo(config, "non-existent-package")
+	projectInfo, err = GetGitlabProjectInfo(nil, config, "non-existent-package")
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -56,7 +63,7 @@ func TestGitlabGetNonExistentPackageInfo(t *testing.T) {
 	}
 
 	// Test a package that does not exist in the project
-	projectInfo, err := GetGitlabProjectInfo(config, "non-existent-package")
+	projectInfo, err := GetGitlabProjectInfo(nil, config, "non-existent-package")
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -75,7 +82,7 @@ func TestGitlabGetPackageInfoUnauthorized(t *testing.T) {
 		PrivateToken:          "NOT_AUTHORIZED",
 	}
 
-	_, err := GetGitlabProjectInfo(config, "promptlib")
+	_, err := GetGitlabProjectInfo(nil, config, "promptlib")
 
 	if err == nil || err.Error() != "Project information not found in the JSON response: 401 Unauthorized" {
 		t.Errorf("Unexpected error: %v", err)
@@ -103,3 +110,222 @@ func TestLoadValidGitlabRegistryConfig(t *testing.T) {
 		t.Errorf("Expected PrivateToken to be '%s', got '%s'", expectedConfig.PrivateToken, config.PrivateToken)
 	}
 }
+
+func TestLoadGitlabRegistryConfigs(t *testing.T) {
+	configs, err := LoadGitlabRegistryConfigs("resources/valid-gitlab-registries.toml")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []GitlabRegistryConfig{
+		{
+			Name:                "acme",
+			GroupApiPackagesUrl: "https://gitlab.example.com/api/v4/groups/42/packages",
+			PrivateToken:        "GROUP_TOKEN",
+		},
+		{ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/12345678/packages"},
+	}
+
+	if !reflect.DeepEqual(configs, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, configs)
+	}
+
+	if names := []string{configs[0].RegistryName(), configs[1].RegistryName()}; !reflect.DeepEqual(names, []string{"acme", "gitlab"}) {
+		t.Errorf("Unexpected registry names: %v", names)
+	}
+
+	// Single registry
+	configs, err = LoadGitlabRegistryConfigs("resources/valid-gitlab-config.toml")
+
+	if err != nil || len(configs) != 1 || configs[0].PackagesUrl() != "https://gitlab.com/api/v4/projects/12345678/packages" {
+		t.Errorf("Unexpected registries: %+v (%v)", configs, err)
+	}
+
+	// No registry
+	configs, err = LoadGitlabRegistryConfigs("resources/valid-settings.toml")
+
+	if err != nil || len(configs) != 0 {
+		t.Errorf("Expected no registry, but got %+v (%v)", configs, err)
+	}
+
+	// Neither project nor group
+	path := filepath.Join(t.TempDir(), "invalid.toml")
+
+	if err := os.WriteFile(path, []byte("[[gitlab]]\nname = \"broken\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadGitlabRegistryConfigs(path); err == nil {
+		t.Errorf("Expected error for registry without packages URL")
+	}
+}
+
+func TestGetGitlabProjectInfoPaginated(t *testing.T) {
+	// Versions not ordered, over 3 pages, with packages of similar names
+	pages := [][]string{
+		{`{"name": "acme-lib", "version": "1.10.0", "_links": {"web_path": "/acme/lib/-/packages/10"}}`,
+			`{"name": "acme-lib-extra", "version": "9.0.0", "_links": {"web_path": "/acme/extra/-/packages/90"}}`},
+		{`{"name": "acme_lib", "version": "2.0.0", "_links": {"web_path": "/acme/lib/-/packages/20"}}`,
+			`{"name": "acme-lib", "version": "1.9.0", "_links": {"web_path": "/acme/lib/-/packages/9"}}`},
+		{`{"name": "acme-lib", "version": "2.0.0rc1", "_links": {"web_path": "/acme/lib/-/packages/19"}}`},
+	}
+
+	var tokens, names []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Private-Token"))
+
+		query := r.URL.Query()
+		names = append(names, query.Get("package_name"))
+
+		if r.URL.Path != "/api/v4/groups/42/packages" ||
+			query.Get("package_type") != "pypi" || query.Get("per_page") != "100" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "404 Not Found"}`)
+
+			return
+		}
+
+		if query.Get("package_name") != "acme-lib" {
+			fmt.Fprint(w, "[]")
+
+			return
+		}
+
+		page, _ := strconv.Atoi(query.Get("page"))
+
+		if page < len(pages) {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+
+		fmt.Fprint(w, "[")
+
+		for i, pkg := range pages[page-1] {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+
+			fmt.Fprint(w, pkg)
+		}
+
+		fmt.Fprint(w, "]")
+	}))
+
+	defer server.Close()
+
+	config := GitlabRegistryConfig{
+		GroupApiPackagesUrl: server.URL + "/api/v4/groups/42/packages",
+		PrivateToken:        "secret",
+	}
+
+	info, err := GetGitlabProjectInfo(server.Client(), config, "acme-lib")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if info == nil || info.Version != "v2.0.0" || info.HomeURL != server.URL+"/acme/lib/-/packages/20" {
+		t.Fatalf("Expected the highest version from the second page, but got %+v", info)
+	}
+
+	if url := info.Releases[3].Url; url != server.URL+"/acme/lib/-/packages/19" {
+		t.Errorf("Expected the URL of the pre-release, but got %s", url)
+	}
+
+	if len(info.Releases) != 4 {
+		t.Errorf("Expected the releases of acme-lib only, but got %v", info.Releases)
+	}
+
+	if !reflect.DeepEqual(tokens, []string{"secret", "secret", "secret"}) {
+		t.Errorf("Expected 3 authenticated requests, but got %v", tokens)
+	}
+
+	// Not found, with the name escaped in the query
+	if info, err := GetGitlabProjectInfo(server.Client(), config, "unknown+lib&page=1"); err != nil || info != nil {
+		t.Errorf("Expected no project, but got %+v (%v)", info, err)
+	}
+
+	if name := names[len(names)-1]; name != "unknown+lib&page=1" {
+		t.Errorf("Expected the package name to be escaped, but got %s", name)
+	}
+}
+
+func TestGetGitlabProjectInfoAuthentication(t *testing.T) {
+	var headers []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+
+		headers = append(headers, fmt.Sprintf("private=%s job=%s basic=%s:%s",
+			r.Header.Get("Private-Token"), r.Header.Get("Job-Token"), user, password))
+
+		fmt.Fprint(w, "[]")
+	}))
+
+	defer server.Close()
+
+	configs := []GitlabRegistryConfig{
+		{PrivateToken: "personal"},
+		{JobToken: "job"},
+		{DeployTokenUsername: "deployer", DeployToken: "deploy"},
+		{},
+	}
+
+	for _, config := range configs {
+		config.ProjectApiPackagesUrl = server.URL + "/api/v4/projects/1/packages"
+
+		if _, err := GetGitlabProjectInfo(server.Client(), config, "foo"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := []string{
+		"private=personal job= basic=:",
+		"private= job=job basic=:",
+		"private= job= basic=deployer:deploy",
+		"private= job= basic=:",
+	}
+
+	if !reflect.DeepEqual(headers, expected) {
+		t.Errorf("Expected credentials %v, but got %v", expected, headers)
+	}
+}
+
+func TestGetGitlabProjectInfoUnavailableFromCI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v4/projects/1/packages" {
+			// Package registry disabled
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"403 Forbidden"}`)
+
+			return
+		}
+
+		// Project not allowed for the job token
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+	}))
+
+	defer server.Close()
+
+	for _, project := range []string{"1", "2"} {
+		config := GitlabRegistryConfig{
+			ProjectApiPackagesUrl: server.URL + "/api/v4/projects/" + project + "/packages",
+			JobToken:              "job",
+		}
+
+		if _, err := GetGitlabProjectInfo(server.Client(), config, "foo"); err == nil {
+			t.Errorf("Expected error for project %s, configured explicitly", project)
+		}
+
+		config.FromCI = true
+
+		info, err := GetGitlabProjectInfo(server.Client(), config, "foo")
+
+		if err != nil || info != nil {
+			t.Errorf("Expected package not found for project %s, configured from the CI, but got %v (%v)",
+				project, info, err)
+		}
+	}
+}
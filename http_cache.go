@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CachingTransport is an HTTP transport caching the responses
+// of the GET requests on disk.
+//
+// A cached response is used as-is until the TTL expires,
+// then it's revalidated using its `ETag` and/or `Last-Modified` headers
+// (the cached response being used if the server replies `304 Not Modified`).
+type CachingTransport struct {
+	// Directory where the responses are cached
+	Dir string
+
+	// Duration during which a cached response is used without revalidation
+	TTL time.Duration
+
+	// Transport used to send the requests (http.DefaultTransport if nil)
+	Transport http.RoundTripper
+}
+
+// cachedResponse is the representation of a cached response on disk.
+type cachedResponse struct {
+	Url        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+// Headers for which the requests are cached separately
+// (e.g. the authentication tokens)
+var cacheVaryingHeaders = []string{"Authorization", "Private-Token"}
+
+// Headers for which the requests are cached separately according their presence
+// (the kind of authentication), but not their value: the Gitlab CI job token
+// is different for each job, so its value would prevent reusing the responses
+var cacheAuthKindHeaders = []string{"Job-Token"}
+
+// DefaultCacheDir returns the default directory for the HTTP cache
+// (e.g. `~/.cache/wilf` on Linux).
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "wilf")
+}
+
+// NewCachingClient returns an HTTP client with a CachingTransport
+// for the given directory and TTL.
+func NewCachingClient(dir string, ttl time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &CachingTransport{Dir: dir, TTL: ttl},
+	}
+}
+
+// RoundTrip is a method of the http.RoundTripper interface.
+func (t *CachingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport := t.Transport
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	if request.Method != http.MethodGet {
+		return transport.RoundTrip(request)
+	}
+
+	path := t.path(request)
+	cached := t.load(path)
+
+	if cached != nil && time.Since(cached.StoredAt) < t.TTL {
+		log.Debugf("Using cached response for %s", request.URL.Redacted())
+
+		return cached.response(request), nil
+	}
+
+	if cached != nil {
+		// Revalidate the cached response
+		request = request.Clone(request.Context())
+
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			request.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	response, err := transport.RoundTrip(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && response.StatusCode == http.StatusNotModified {
+		log.Debugf("Cached response still valid for %s", request.URL.Redacted())
+
+		response.Body.Close()
+
+		for _, name := range []string{"ETag", "Last-Modified", "Cache-Control", "Expires"} {
+			if value := response.Header.Get(name); value != "" {
+				cached.Header.Set(name, value)
+			}
+		}
+
+		cached.StoredAt = time.Now()
+		t.store(path, *cached)
+
+		return cached.response(request), nil
+	}
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+		return response, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.store(path, cachedResponse{
+		Url:        request.URL.Redacted(),
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       body,
+		StoredAt:   time.Now(),
+	})
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	return response, nil
+}
+
+// path returns the path of the cache file for the request.
+func (t *CachingTransport) path(request *http.Request) string {
+	hash := sha256.New()
+
+	fmt.Fprintln(hash, request.URL.String())
+
+	for _, name := range cacheVaryingHeaders {
+		fmt.Fprintf(hash, "%s: %s\n", name, request.Header.Get(name))
+	}
+
+	for _, name := range cacheAuthKindHeaders {
+		fmt.Fprintf(hash, "%s: %v\n", name, request.Header.Get(name) != "")
+	}
+
+	return filepath.Join(t.Dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// load returns the cached response from the given path,
+// or nil if not found or invalid.
+func (t *CachingTransport) load(path string) *cachedResponse {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil
+	}
+
+	var cached cachedResponse
+
+	if err := json.Unmarshal(content, &cached); err != nil {
+		log.Debugf("Ignoring invalid cache file %s: %s", path, err.Error())
+
+		return nil
+	}
+
+	return &cached
+}
+
+// store writes the cached response to the given path.
+// A failure is only logged, as the cache is optional.
+//
+// The file is only readable by the user, as the response can be
+// from an authenticated private registry.
+func (t *CachingTransport) store(path string, cached cachedResponse) {
+	content, err := json.Marshal(cached)
+
+	if err == nil {
+		err = os.MkdirAll(t.Dir, 0700)
+	}
+
+	if err == nil {
+		err = writeFileAtomically(t.Dir, path, content)
+	}
+
+	if err != nil {
+		log.Warnf("Fails to cache response for %s: %s", cached.Url, err.Error())
+	}
+}
+
+// writeFileAtomically writes the content to a temporary file of the directory,
+// then renames it as the given path, not to leave a partial file,
+// even if the same path is written concurrently.
+func writeFileAtomically(dir string, path string, content []byte) error {
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(content)
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// response returns a response for the request from the cached one.
+func (c cachedResponse) response(request *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       request,
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachingTransport(t *testing.T) {
+	var requests, revalidations int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidations, 1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "content for "+r.Header.Get("PRIVATE-TOKEN"))
+	}))
+
+	defer server.Close()
+
+	transport := &CachingTransport{Dir: t.TempDir(), TTL: time.Hour}
+	client := &http.Client{Transport: transport}
+
+	get := func(token string) string {
+		request, _ := http.NewRequest("GET", server.URL+"/pkg", nil)
+
+		if token != "" {
+			request.Header.Set("PRIVATE-TOKEN", token)
+		}
+
+		response, err := client.Do(request)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, but got %d", response.StatusCode)
+		}
+
+		body, _ := io.ReadAll(response.Body)
+
+		return string(body)
+	}
+
+	// Fresh: the second request is served from the cache
+	if body := get(""); body != "content for " {
+		t.Errorf("Unexpected body: %s", body)
+	}
+
+	if body := get(""); body != "content for " {
+		t.Errorf("Unexpected cached body: %s", body)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request to the server, but got %d", n)
+	}
+
+	// The responses are cached separately for different tokens
+	if body := get("secret"); body != "content for secret" {
+		t.Errorf("Unexpected body: %s", body)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected 2 requests to the server, but got %d", n)
+	}
+
+	// Stale: the cached response is revalidated using the ETag
+	transport.TTL = 0
+
+	if body := get(""); body != "content for " {
+		t.Errorf("Unexpected revalidated body: %s", body)
+	}
+
+	if n := atomic.LoadInt32(&revalidations); n != 1 {
+		t.Errorf("Expected 1 revalidation, but got %d", n)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(transport.Dir, "*.json"))
+
+	if len(files) != 2 {
+		t.Errorf("Expected 2 cache files, but got %d", len(files))
+	}
+}
+
+func TestCachingTransportErrors(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	client := NewCachingClient(dir, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		response, err := client.Get(server.URL)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, but got %d", response.StatusCode)
+		}
+	}
+
+	// The server errors are not cached
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected 2 requests to the server, but got %d", n)
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected no cache directory, but got %v", err)
+	}
+}
+
+func TestCachingTransportJobToken(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		io.WriteString(w, "authenticated: "+r.Header.Get("JOB-TOKEN"))
+	}))
+
+	defer server.Close()
+
+	client := &http.Client{Transport: &CachingTransport{Dir: t.TempDir(), TTL: time.Hour}}
+
+	get := func(token string) string {
+		request, _ := http.NewRequest("GET", server.URL+"/pkg", nil)
+
+		if token != "" {
+			request.Header.Set("JOB-TOKEN", token)
+		}
+
+		response, err := client.Do(request)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		defer response.Body.Close()
+
+		body, _ := io.ReadAll(response.Body)
+
+		return string(body)
+	}
+
+	// The job token of a next job reuses the cached response
+	for _, token := range []string{"job-1", "job-2"} {
+		if body := get(token); body != "authenticated: job-1" {
+			t.Errorf("Unexpected body for %s: %s", token, body)
+		}
+	}
+
+	// ... but not the anonymous requests
+	if body := get(""); body != "authenticated: " {
+		t.Errorf("Unexpected anonymous body: %s", body)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected 2 requests to the server, but got %d", n)
+	}
+}
+
+func TestCachingTransportStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	transport := &CachingTransport{Dir: dir, TTL: time.Hour}
+	path := filepath.Join(dir, "key.json")
+
+	// Concurrent writes of the same key
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			transport.store(path, cachedResponse{
+				Url:        "https://pypi.org/pypi/requests/json",
+				StatusCode: http.StatusOK,
+				Body:       []byte(strings.Repeat("x", 1024*i)),
+				StoredAt:   time.Now(),
+			})
+		}(i)
+	}
+
+	wg.Wait()
+
+	if cached := transport.load(path); cached == nil || cached.StatusCode != http.StatusOK {
+		t.Errorf("Expected a valid cached response, but got %+v", cached)
+	}
+
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("Unexpected temporary files: %v", tmp)
+	}
+
+	// Only accessible by the user
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected cache directory with mode 0700, but got %v (%v)", info, err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected cache file with mode 0600, but got %v (%v)", info, err)
+	}
+}
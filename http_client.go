@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// HttpConfig represents the configuration of the HTTP requests to the registries
+// (`[http]` section), with the durations parsed from their representations.
+type HttpConfig struct {
+	// Timeout of each request, and of the whole check (none if zero)
+	Timeout           time.Duration
+	TimeoutRepr       string `toml:"timeout"`
+	GlobalTimeout     time.Duration
+	GlobalTimeoutRepr string `toml:"global_timeout"`
+
+	// Maximum number of retries of a request failing with a transient error
+	Retries int `toml:"retries"`
+
+	// Delay before the first retry (doubled for each next one), and maximum delay
+	Backoff        time.Duration
+	BackoffRepr    string `toml:"backoff"`
+	MaxBackoff     time.Duration
+	MaxBackoffRepr string `toml:"max_backoff"`
+
+	// Maximum number of requests per second to a same host (unlimited if zero)
+	RateLimit float64 `toml:"rate_limit"`
+
+	// Proxy for all the requests (the environment variables are used if empty),
+	// except for the hosts or domains of NoProxy
+	Proxy   string   `toml:"proxy"`
+	NoProxy []string `toml:"no_proxy"`
+
+	// Paths of the CA bundles trusted in addition to the system ones
+	// (e.g. for an intercepting proxy)
+	CaBundles []string `toml:"ca_bundles"`
+
+	// TLS settings by host (e.g. client certificates)
+	Tls []HostTlsConfig `toml:"tls"`
+}
+
+// DefaultHttpConfig returns the default configuration of the HTTP requests:
+// a timeout of 30 seconds per request, and 3 retries from 500ms up to 30 seconds.
+func DefaultHttpConfig() HttpConfig {
+	return HttpConfig{
+		Timeout:    30 * time.Second,
+		Retries:    3,
+		Backoff:    500 * time.Millisecond,
+		MaxBackoff: 30 * time.Second,
+	}
+}
+
+// LoadHttpConfig loads the configuration of the HTTP requests
+// from the `[http]` section of a TOML file, with the default values
+// for the missing fields (see DefaultHttpConfig).
+func LoadHttpConfig(path string) (*HttpConfig, error) {
+	var config struct {
+		Http HttpConfig `toml:"http"`
+	}
+
+	md, err := toml.DecodeFile(path, &config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := DefaultHttpConfig()
+	httpConfig := config.Http
+
+	durations := []struct {
+		name     string
+		repr     string
+		value    *time.Duration
+		fallback time.Duration
+	}{
+		{"timeout", httpConfig.TimeoutRepr, &httpConfig.Timeout, defaults.Timeout},
+		{"global timeout", httpConfig.GlobalTimeoutRepr, &httpConfig.GlobalTimeout, defaults.GlobalTimeout},
+		{"backoff", httpConfig.BackoffRepr, &httpConfig.Backoff, defaults.Backoff},
+		{"max backoff", httpConfig.MaxBackoffRepr, &httpConfig.MaxBackoff, defaults.MaxBackoff},
+	}
+
+	for _, d := range durations {
+		if d.repr == "" {
+			*d.value = d.fallback
+
+			continue
+		}
+
+		value, err := time.ParseDuration(d.repr)
+
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid HTTP %s: %s", d.name, d.repr)
+		}
+
+		*d.value = value
+	}
+
+	if !md.IsDefined("http", "retries") {
+		httpConfig.Retries = defaults.Retries
+	}
+
+	if httpConfig.Retries < 0 {
+		return nil, fmt.Errorf("invalid HTTP retries: %d", httpConfig.Retries)
+	}
+
+	if httpConfig.RateLimit < 0 {
+		return nil, fmt.Errorf("invalid HTTP rate limit: %v", httpConfig.RateLimit)
+	}
+
+	return &httpConfig, nil
+}
+
+// HttpClientFactory creates the HTTP clients used to query the registries,
+// according the cache and offline options, and the HTTP configuration.
+type HttpClientFactory struct {
+	// Directory and TTL of the HTTP cache (see CachingTransport)
+	CacheDir string
+	CacheTTL time.Duration
+
+	// Whether the HTTP cache is disabled
+	NoCache bool
+
+	// Directory of the offline snapshot, if any (see OfflineTransport)
+	Offline string
+
+	// Directory where to export a snapshot, if any (see SnapshotTransport)
+	Snapshot string
+
+	// Timeouts, retries and rate limit of the requests (see RetryTransport)
+	Http HttpConfig
+
+	// Time after which no request is sent anymore (none if zero),
+	// according the global timeout
+	Deadline time.Time
+
+	// Rate limiter shared by all the clients (none if nil)
+	RateLimiter *RateLimiter
+
+	// Proxy and TLS settings (the default ones if nil)
+	network *networkConfig
+}
+
+// NewHttpClientFactory returns a factory for the HTTP configuration,
+// with the deadline and the rate limiter (if configured) starting from now.
+// It returns an error if the proxy or TLS settings are not valid
+// (e.g. a CA bundle which cannot be read).
+func NewHttpClientFactory(httpConfig HttpConfig) (HttpClientFactory, error) {
+	network, err := loadNetworkConfig(httpConfig)
+
+	if err != nil {
+		return HttpClientFactory{}, err
+	}
+
+	factory := HttpClientFactory{Http: httpConfig, network: network}
+
+	if httpConfig.GlobalTimeout > 0 {
+		factory.Deadline = time.Now().Add(httpConfig.GlobalTimeout)
+	}
+
+	if httpConfig.RateLimit > 0 {
+		factory.RateLimiter = NewRateLimiter(httpConfig.RateLimit)
+	}
+
+	return factory, nil
+}
+
+// Client returns an HTTP client for the registry at the given URL,
+// with the proxy and TLS settings of its host (see HostTlsConfig),
+// verifying the TLS certificates or not (e.g. for a source with `verify_ssl = false`).
+func (f HttpClientFactory) Client(registryUrl string, verifySsl bool) *http.Client {
+	if f.Offline != "" {
+		return NewOfflineClient(f.Offline)
+	}
+
+	var transport http.RoundTripper = f.network.transport(registryUrl, verifySsl)
+
+	// Only the requests actually sent are retried and rate limited (not the cached ones)
+	transport = &RetryTransport{
+		Transport:   transport,
+		Timeout:     f.Http.Timeout,
+		Deadline:    f.Deadline,
+		Retries:     f.Http.Retries,
+		Backoff:     f.Http.Backoff,
+		MaxBackoff:  f.Http.MaxBackoff,
+		RateLimiter: f.RateLimiter,
+	}
+
+	if !f.NoCache {
+		transport = &CachingTransport{
+			Dir:       f.CacheDir,
+			TTL:       f.CacheTTL,
+			Transport: transport,
+		}
+	}
+
+	if f.Snapshot != "" {
+		transport = &SnapshotTransport{
+			Dir:       f.Snapshot,
+			Transport: transport,
+		}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// TransientError is the error of a registry which is temporarily unavailable
+// (e.g. `503 Service Unavailable` or `429 Too Many Requests`).
+type TransientError struct {
+	// URL of the request (with the password masked)
+	Url string
+
+	// Status of the response
+	Status string
+}
+
+func (e *TransientError) Error() string {
+	return fmt.Sprintf("fails to get project information from %s: %s", e.Url, e.Status)
+}
+
+// checkTransientStatus returns a TransientError if the status of the response
+// to the request for the URL indicates that the registry is temporarily unavailable
+// (`429` or `5xx`).
+func checkTransientStatus(requestUrl string, response *http.Response) error {
+	if !isTransientStatus(response.StatusCode) {
+		return nil
+	}
+
+	return &TransientError{
+		Url:    redactedUrl(requestUrl),
+		Status: response.Status,
+	}
+}
+
+// isTransientStatus checks whether the HTTP status indicates
+// that the registry is temporarily unavailable (`429` or `5xx`).
+func isTransientStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code <= 599)
+}
+
+// IsTransientError checks whether the error is likely to be temporary,
+// so the check can fall back to another registry: either a TransientError,
+// a network error (e.g. connection refused or reset) or a timeout.
+func IsTransientError(err error) bool {
+	var transient *TransientError
+
+	if errors.As(err, &transient) {
+		return true
+	}
+
+	var opErr *net.OpError
+
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	var netErr net.Error
+
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.New("invalid JSON"), false},
+		{&TransientError{Url: "https://pypi.org/pypi/foo/json", Status: "503 Service Unavailable"}, true},
+		{fmt.Errorf("wrapped: %w", &TransientError{}), true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("unexpected: %w", io.ErrUnexpectedEOF), true},
+	}
+
+	for _, test := range tests {
+		if transient := IsTransientError(test.err); transient != test.expected {
+			t.Errorf("Expected transient %t for %v, but got %t", test.expected, test.err, transient)
+		}
+	}
+}
+
+func TestGetProjectInfoTransientStatus(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		_, err := GetProjectInfo(server.Client(), server.URL, "foo")
+
+		server.Close()
+
+		if !IsTransientError(err) {
+			t.Errorf("Expected transient error for status %d, but got %v", status, err)
+		}
+	}
+}
+
+func TestLoadHttpConfig(t *testing.T) {
+	verifySsl := false
+
+	tests := []struct {
+		path     string
+		expected HttpConfig
+	}{
+		{"resources/valid-settings.toml", DefaultHttpConfig()},
+		{
+			"resources/valid-http-config.toml",
+			HttpConfig{
+				Timeout:           10 * time.Second,
+				TimeoutRepr:       "10s",
+				GlobalTimeout:     5 * time.Minute,
+				GlobalTimeoutRepr: "5m",
+				Retries:           0,
+				Backoff:           time.Second,
+				BackoffRepr:       "1s",
+				MaxBackoff:        30 * time.Second,
+				RateLimit:         5,
+				Proxy:             "http://proxy.example.com:3128",
+				NoProxy:           []string{".example.com"},
+				CaBundles:         []string{"/etc/ssl/certs/corporate-ca.pem"},
+				Tls: []HostTlsConfig{{
+					Host:       "pypi.example.com",
+					ClientCert: "/etc/wilf/client.pem",
+					ClientKey:  "/etc/wilf/client-key.pem",
+					VerifySsl:  &verifySsl,
+				}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		config, err := LoadHttpConfig(test.path)
+
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", test.path, err)
+		}
+
+		if !reflect.DeepEqual(*config, test.expected) {
+			t.Errorf("Expected %+v for %s, but got %+v", test.expected, test.path, *config)
+		}
+	}
+}
+
+func TestNewHttpClientFactory(t *testing.T) {
+	factory, err := NewHttpClientFactory(DefaultHttpConfig())
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !factory.Deadline.IsZero() || factory.RateLimiter != nil {
+		t.Errorf("Expected neither deadline nor rate limit by default: %+v", factory)
+	}
+
+	factory, err = NewHttpClientFactory(HttpConfig{GlobalTimeout: time.Minute, RateLimit: 2})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if factory.Deadline.IsZero() || factory.RateLimiter == nil {
+		t.Errorf("Expected a deadline and a rate limiter: %+v", factory)
+	}
+
+	if factory.Client(DefaultPypiJsonUrl, true).Transport.(*CachingTransport).Transport.(*RetryTransport).RateLimiter != factory.RateLimiter {
+		t.Errorf("Expected the rate limiter to be shared by the clients")
+	}
+}
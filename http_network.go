@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// HostTlsConfig represents the TLS settings for the registries on a host
+// (`[[http.tls]]` section), e.g. for mutual TLS authentication.
+type HostTlsConfig struct {
+	// Host name of the registries (e.g. `pypi.example.com`),
+	// or pattern (e.g. `*.example.com`, see path.Match)
+	Host string `toml:"host"`
+
+	// Paths of the client certificate and of its private key (PEM encoded), if any
+	ClientCert string `toml:"client_cert"`
+	ClientKey  string `toml:"client_key"`
+
+	// Whether the certificate of the host is verified (true if nil)
+	VerifySsl *bool `toml:"verify_ssl"`
+}
+
+// Matches checks whether the TLS settings apply to the host.
+func (c HostTlsConfig) Matches(host string) bool {
+	matches, _ := path.Match(strings.ToLower(c.Host), strings.ToLower(host))
+
+	return matches
+}
+
+// networkConfig represents the proxy and TLS settings of the HTTP clients,
+// with the certificates loaded from the files of the HttpConfig.
+type networkConfig struct {
+	// Proxy URL, or nil to use the environment (`HTTPS_PROXY`, ...)
+	proxy *url.URL
+
+	// Hosts (or domains, e.g. `.example.com`) accessed without proxy
+	noProxy []string
+
+	// Trusted CA certificates: the ones of the system and of the bundles,
+	// or nil to use the ones of the system only
+	rootCAs *x509.CertPool
+
+	// TLS settings by host, and the client certificates by host pattern
+	hosts        []HostTlsConfig
+	certificates map[string]tls.Certificate
+}
+
+// loadNetworkConfig loads the proxy and TLS settings of the HttpConfig,
+// reading the CA bundles and the client certificates.
+func loadNetworkConfig(httpConfig HttpConfig) (*networkConfig, error) {
+	config := networkConfig{
+		noProxy:      httpConfig.NoProxy,
+		hosts:        httpConfig.Tls,
+		certificates: make(map[string]tls.Certificate),
+	}
+
+	if httpConfig.Proxy != "" {
+		proxy, err := url.Parse(httpConfig.Proxy)
+
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid HTTP proxy: %s", redactedUrl(httpConfig.Proxy))
+		}
+
+		config.proxy = proxy
+	}
+
+	if len(httpConfig.CaBundles) > 0 {
+		pool, err := x509.SystemCertPool()
+
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		for _, bundle := range httpConfig.CaBundles {
+			content, err := os.ReadFile(bundle)
+
+			if err != nil {
+				return nil, fmt.Errorf("fails to read CA bundle '%s': %s", bundle, err.Error())
+			}
+
+			if !pool.AppendCertsFromPEM(content) {
+				return nil, fmt.Errorf("no certificate found in CA bundle '%s'", bundle)
+			}
+		}
+
+		config.rootCAs = pool
+	}
+
+	for _, host := range httpConfig.Tls {
+		if _, err := path.Match(host.Host, ""); err != nil || host.Host == "" {
+			return nil, fmt.Errorf("invalid TLS host: '%s'", host.Host)
+		}
+
+		if host.ClientCert == "" && host.ClientKey == "" {
+			continue
+		}
+
+		certificate, err := tls.LoadX509KeyPair(host.ClientCert, host.ClientKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("fails to load client certificate for '%s': %s", host.Host, err.Error())
+		}
+
+		config.certificates[host.Host] = certificate
+	}
+
+	return &config, nil
+}
+
+// transport returns an HTTP transport for the registry at the given URL,
+// with the TLS settings of its host, verifying its certificate or not
+// (e.g. for a source with `verify_ssl = false`).
+func (c *networkConfig) transport(registryUrl string, verifySsl bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{}
+
+	var host string
+
+	if u, err := url.Parse(registryUrl); err == nil {
+		host = u.Hostname()
+	}
+
+	if c != nil {
+		tlsConfig.RootCAs = c.rootCAs
+
+		for _, hostConfig := range c.hosts {
+			if !hostConfig.Matches(host) {
+				continue
+			}
+
+			if hostConfig.VerifySsl != nil && !*hostConfig.VerifySsl {
+				verifySsl = false
+			}
+
+			if certificate, ok := c.certificates[hostConfig.Host]; ok {
+				tlsConfig.Certificates = []tls.Certificate{certificate}
+			}
+
+			break
+		}
+
+		if c.proxy != nil {
+			transport.Proxy = c.proxyFunc()
+		}
+	}
+
+	tlsConfig.InsecureSkipVerify = !verifySsl
+	transport.TLSClientConfig = tlsConfig
+
+	return transport
+}
+
+// proxyFunc returns the function selecting the configured proxy,
+// except for the hosts of `no_proxy`.
+func (c *networkConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(request *http.Request) (*url.URL, error) {
+		host := strings.ToLower(request.URL.Hostname())
+
+		for _, excluded := range c.noProxy {
+			excluded = strings.ToLower(excluded)
+
+			if host == strings.TrimPrefix(excluded, ".") || strings.HasSuffix(host, "."+strings.TrimPrefix(excluded, ".")) {
+				return nil, nil
+			}
+		}
+
+		return c.proxy, nil
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeClientCertificate generates a self-signed client certificate,
+// written with its key as PEM files in the directory.
+func writeClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "wilf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	certificate, _ := x509.ParseCertificate(der)
+
+	return certificate, certPath, keyPath
+}
+
+func TestHttpClientFactoryTls(t *testing.T) {
+	dir := t.TempDir()
+
+	clientCert, certPath, keyPath := writeClientCertificate(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+
+	defer server.Close()
+
+	// CA bundle of the server
+	bundle := filepath.Join(dir, "ca.pem")
+
+	os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0600)
+
+	verifySsl := false
+
+	tests := []struct {
+		name    string
+		config  HttpConfig
+		success bool
+	}{
+		{"no CA bundle", HttpConfig{}, false},
+		{"no client certificate", HttpConfig{CaBundles: []string{bundle}}, false},
+		{
+			"CA bundle and client certificate",
+			HttpConfig{
+				CaBundles: []string{bundle},
+				Tls:       []HostTlsConfig{{Host: "127.0.0.*", ClientCert: certPath, ClientKey: keyPath}},
+			},
+			true,
+		},
+		{
+			"client certificate for another host",
+			HttpConfig{
+				CaBundles: []string{bundle},
+				Tls:       []HostTlsConfig{{Host: "pypi.example.com", ClientCert: certPath, ClientKey: keyPath}},
+			},
+			false,
+		},
+		{
+			"client certificate without verification",
+			HttpConfig{
+				Tls: []HostTlsConfig{{Host: "127.0.0.1", ClientCert: certPath, ClientKey: keyPath, VerifySsl: &verifySsl}},
+			},
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		factory, err := NewHttpClientFactory(test.config)
+
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", test.name, err)
+		}
+
+		factory.NoCache = true
+
+		response, err := factory.Client(server.URL, true).Get(server.URL)
+
+		if err == nil {
+			response.Body.Close()
+		}
+
+		if (err == nil) != test.success {
+			t.Errorf("Unexpected result for %s: %v", test.name, err)
+		}
+	}
+}
+
+func TestLoadNetworkConfigErrors(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+
+	os.WriteFile(empty, []byte("no certificate"), 0600)
+
+	configs := []HttpConfig{
+		{Proxy: "://invalid"},
+		{CaBundles: []string{"/non/existing/ca.pem"}},
+		{CaBundles: []string{empty}},
+		{Tls: []HostTlsConfig{{Host: "["}}},
+		{Tls: []HostTlsConfig{{Host: "pypi.example.com", ClientCert: empty, ClientKey: empty}}},
+	}
+
+	for _, config := range configs {
+		if _, err := NewHttpClientFactory(config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
+
+func TestNetworkConfigProxy(t *testing.T) {
+	network, err := loadNetworkConfig(HttpConfig{
+		Proxy:   "http://proxy.example.com:3128",
+		NoProxy: []string{"gitlab.example.com", ".internal"},
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	proxy := network.transport("https://pypi.org/simple", true).Proxy
+
+	tests := map[string]string{
+		"https://pypi.org/pypi/foo/json":            "http://proxy.example.com:3128",
+		"https://gitlab.example.com/api/v4/":        "",
+		"https://nexus.corp.internal/simple/":       "",
+		"https://internal/simple/":                  "",
+		"https://notgitlab.example.com/api/v4/foo/": "http://proxy.example.com:3128",
+	}
+
+	for rawUrl, expected := range tests {
+		u, _ := url.Parse(rawUrl)
+
+		proxyUrl, err := proxy(&http.Request{URL: u})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if (proxyUrl == nil && expected != "") || (proxyUrl != nil && proxyUrl.String() != expected) {
+			t.Errorf("Expected proxy '%s' for %s, but got %v", expected, rawUrl, proxyUrl)
+		}
+	}
+}
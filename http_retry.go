@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryTransport is an HTTP transport retrying the requests
+// failing with a transient error (see IsTransientError),
+// with an exponential backoff (or according the `Retry-After` header),
+// and limiting the duration and the rate of the requests.
+type RetryTransport struct {
+	// Transport used to send the requests (http.DefaultTransport if nil)
+	Transport http.RoundTripper
+
+	// Timeout of each attempt (none if zero)
+	Timeout time.Duration
+
+	// Time after which no request is sent anymore (none if zero)
+	Deadline time.Time
+
+	// Maximum number of retries after the first attempt
+	Retries int
+
+	// Delay before the first retry, doubled for each next one
+	// up to MaxBackoff (which also limits the `Retry-After` delay)
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Rate limiter for the requests (none if nil)
+	RateLimiter *RateLimiter
+}
+
+// RoundTrip is a method of the http.RoundTripper interface.
+func (t *RetryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport := t.Transport
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	// A request with a body cannot be sent again
+	retries := t.Retries
+
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if !t.Deadline.IsZero() && time.Now().After(t.Deadline) {
+			return nil, fmt.Errorf("global HTTP timeout exceeded before %s", request.URL.Redacted())
+		}
+
+		if t.RateLimiter != nil {
+			if err := t.RateLimiter.Wait(request.Context(), request.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := t.attempt(transport, request)
+
+		if attempt >= retries || !t.isRetryable(request, response, err) {
+			return response, err
+		}
+
+		delay := t.delay(attempt, response)
+
+		if !t.Deadline.IsZero() && time.Now().Add(delay).After(t.Deadline) {
+			return response, err
+		}
+
+		if err != nil {
+			log.Debugf("Retrying %s in %s after error: %s", request.URL.Redacted(), delay, err.Error())
+		} else {
+			log.Debugf("Retrying %s in %s after status: %s", request.URL.Redacted(), delay, response.Status)
+
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		if err := sleepContext(request.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+
+			if err != nil {
+				return nil, err
+			}
+
+			request = request.Clone(request.Context())
+			request.Body = body
+		}
+	}
+}
+
+// attempt sends the request once, within the timeout and the deadline (if any).
+func (t *RetryTransport) attempt(
+	transport http.RoundTripper,
+	request *http.Request,
+) (*http.Response, error) {
+	deadline := t.Deadline
+
+	if t.Timeout > 0 {
+		if d := time.Now().Add(t.Timeout); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+
+	if deadline.IsZero() {
+		return transport.RoundTrip(request)
+	}
+
+	ctx, cancel := context.WithDeadline(request.Context(), deadline)
+
+	response, err := transport.RoundTrip(request.WithContext(ctx))
+
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	// The context is released once the body is read
+	response.Body = &cancelOnClose{ReadCloser: response.Body, cancel: cancel}
+
+	return response, nil
+}
+
+// isRetryable checks whether the attempt can be retried:
+// either a transient error (including the timeout of the attempt),
+// or a transient status (`429` or `5xx`).
+func (t *RetryTransport) isRetryable(
+	request *http.Request,
+	response *http.Response,
+	err error,
+) bool {
+	if request.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return IsTransientError(err) || errors.Is(err, context.DeadlineExceeded)
+	}
+
+	return isTransientStatus(response.StatusCode)
+}
+
+// delay returns the delay before the next attempt: either according
+// the `Retry-After` header of the response (if any), or the exponential backoff,
+// limited by MaxBackoff.
+func (t *RetryTransport) delay(attempt int, response *http.Response) time.Duration {
+	delay := t.Backoff << attempt
+
+	if response != nil {
+		if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			delay = retryAfter
+		}
+	}
+
+	if t.MaxBackoff > 0 && (delay > t.MaxBackoff || delay < 0) {
+		delay = t.MaxBackoff
+	}
+
+	return delay
+}
+
+// parseRetryAfter parses the value of a `Retry-After` header:
+// either a number of seconds, or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// sleepContext waits for the given duration, unless the context is done before.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelOnClose releases the context of a request when its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// RateLimiter limits the rate of the requests per host,
+// by spacing them evenly.
+type RateLimiter struct {
+	// Minimum interval between two requests to the same host
+	interval time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing the given number of requests
+// per second and per host.
+func NewRateLimiter(rate float64) *RateLimiter {
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / rate),
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait waits until a request can be sent to the host,
+// unless the context is done before.
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+
+	now := time.Now()
+	slot := l.next[host]
+
+	if slot.Before(now) {
+		slot = now
+	}
+
+	l.next[host] = slot.Add(l.interval)
+
+	l.mu.Unlock()
+
+	return sleepContext(ctx, slot.Sub(now))
+}
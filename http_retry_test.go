@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		statuses []int
+		retries  int
+		expected int
+		attempts int
+	}{
+		{[]int{200}, 3, 200, 1},
+		{[]int{503, 502, 200}, 3, 200, 3},
+		{[]int{429, 200}, 3, 200, 2},
+		{[]int{503, 503, 503}, 2, 503, 3},
+		{[]int{503, 200}, 0, 503, 1},
+		{[]int{404, 200}, 3, 404, 1},
+	}
+
+	for _, test := range tests {
+		attempts := 0
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := test.statuses[attempts]
+			attempts++
+
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			fmt.Fprintf(w, "attempt %d", attempts)
+		}))
+
+		client := &http.Client{Transport: &RetryTransport{
+			Retries:    test.retries,
+			Backoff:    time.Second,
+			MaxBackoff: time.Second,
+		}}
+
+		response, err := client.Get(server.URL)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		server.Close()
+
+		if response.StatusCode != test.expected || attempts != test.attempts ||
+			string(body) != fmt.Sprintf("attempt %d", attempts) {
+			t.Errorf("Expected status %d after %d attempts for %v, but got %d after %d (%s)",
+				test.expected, test.attempts, test.statuses, response.StatusCode, attempts, body)
+		}
+	}
+}
+
+func TestRetryTransportTimeout(t *testing.T) {
+	attempts := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		if attempts == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+
+			return
+		}
+
+		fmt.Fprint(w, "ok")
+	}))
+
+	defer server.Close()
+
+	transport := &RetryTransport{Timeout: 50 * time.Millisecond, Retries: 1, Backoff: time.Millisecond}
+	response, err := (&http.Client{Transport: transport}).Get(server.URL)
+
+	if err != nil {
+		t.Fatalf("Expected the request to be retried after the timeout, but got %v", err)
+	}
+
+	response.Body.Close()
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, but got %d", attempts)
+	}
+
+	// The global deadline is exceeded
+	transport.Deadline = time.Now().Add(-time.Second)
+
+	if _, err := (&http.Client{Transport: transport}).Get(server.URL); err == nil {
+		t.Errorf("Expected error after the deadline")
+	}
+}
+
+func TestRetryTransportDelay(t *testing.T) {
+	transport := RetryTransport{Backoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second}
+
+	tests := []struct {
+		attempt    int
+		retryAfter string
+		expected   time.Duration
+	}{
+		{0, "", 100 * time.Millisecond},
+		{2, "", 400 * time.Millisecond},
+		{10, "", 2 * time.Second},
+		{0, "1", time.Second},
+		{0, "120", 2 * time.Second},
+		{1, "invalid", 200 * time.Millisecond},
+		{0, time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+
+	for _, test := range tests {
+		response := &http.Response{Header: http.Header{}}
+
+		if test.retryAfter != "" {
+			response.Header.Set("Retry-After", test.retryAfter)
+		}
+
+		if delay := transport.delay(test.attempt, response); delay != test.expected {
+			t.Errorf("Expected delay %s for attempt %d with Retry-After '%s', but got %s",
+				test.expected, test.attempt, test.retryAfter, delay)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(20)
+	ctx := context.Background()
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "pypi.org"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if err := limiter.Wait(ctx, "gitlab.com"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 2 intervals of 50ms for the same host, none for another one
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Unexpected duration: %s", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	limiter.Wait(canceled, "pypi.org")
+
+	if err := limiter.Wait(canceled, "pypi.org"); err == nil {
+		t.Errorf("Expected error for canceled context")
+	}
+}
@@ -14,6 +14,13 @@ type JUnitReporter struct {
 	StartTime    time.Time
 	DevTestSuite JUnitTestSuite
 	RunTestSuite JUnitTestSuite
+
+	// Test suites for the dependencies of the other kinds (e.g. optional groups),
+	// or for the groups of updates (e.g. by level)
+	GroupTestSuites []JUnitTestSuite
+
+	// Current group of updates, if grouped
+	group string
 }
 
 const JUnitReporterName = "junit"
@@ -44,7 +51,9 @@ type JUnitTestSuite struct {
 type JUnitTestCase struct {
 	Name      string        `xml:"name,attr"`
 	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Error     *JUnitError   `xml:"error,omitempty"`
 	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
 	Time      float64       `xml:"time,attr"`
 	Timestamp string        `xml:"timestamp,attr"`
 }
@@ -55,6 +64,14 @@ type JUnitFailure struct {
 	Text    string `xml:",chardata"`
 }
 
+// JUnitError is the error of a package which cannot be checked
+// (e.g. registry unavailable).
+type JUnitError struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
 type JUnitSkipped struct {
 	Message string `xml:"message,attr"`
 	Text    string `xml:",chardata"`
@@ -90,31 +107,64 @@ func (r *JUnitReporter) Before(out io.Writer) {
 		Skipped:    0,
 		Time:       0.0,
 	}
+
+	r.GroupTestSuites = []JUnitTestSuite{}
+	r.group = ""
+}
+
+// BeginGroup makes the following updates reported in the test suite for the group
+// (e.g. `major` when grouped by level, or `dev` when grouped by kind).
+func (r *JUnitReporter) BeginGroup(group string, out io.Writer) {
+	r.group = group
+}
+
+// testSuite returns the test suite for the specified kind of dependency
+// (or group of updates), creating it if required.
+func (r *JUnitReporter) testSuite(kind DependencyKind) *JUnitTestSuite {
+	if kind == DevDependency {
+		return &r.DevTestSuite
+	}
+
+	if kind == RunDependency {
+		return &r.RunTestSuite
+	}
+
+	for i, suite := range r.GroupTestSuites {
+		if suite.Name == kind.String() {
+			return &r.GroupTestSuites[i]
+		}
+	}
+
+	r.GroupTestSuites = append(r.GroupTestSuites, JUnitTestSuite{
+		Name:       kind.String(),
+		_timestamp: time.Now(),
+		TestCases:  []JUnitTestCase{},
+	})
+
+	return &r.GroupTestSuites[len(r.GroupTestSuites)-1]
 }
 
 func (r *JUnitReporter) Report(
-	packageName string,
-	requirement VersionRequirement,
-	latestVersion string,
-	updateLevel UpdateLevel,
-	dependencyKind DependencyKind,
-	packageUrl string,
-	fatal bool,
+	update PackageUpdate,
 	excludedPackages []string,
-	timeSec float64,
 	out io.Writer,
 ) error {
+	packageName := update.PackageName
+	updateLevel := update.UpdateLevel
+
 	// Select the appropriate testSuite
-	testSuite := &r.RunTestSuite
+	kind := update.DependencyKind
 
-	if dependencyKind == DevDependency {
-		testSuite = &r.DevTestSuite
+	if r.group != "" {
+		kind = DependencyKind(r.group)
 	}
 
+	testSuite := r.testSuite(kind)
+
 	// Prepare the testCase representation
 	testCase := JUnitTestCase{
 		Name:      fmt.Sprintf("%s %s", packageName, updateLevel),
-		Time:      Trunc(timeSec),
+		Time:      Trunc(update.TimeSec),
 		Timestamp: time.Now().Format("2006-01-02T15:04:05"),
 	}
 
@@ -132,8 +182,41 @@ func (r *JUnitReporter) Report(
 
 	// ---
 
-	if fatal {
-		msg := fmt.Sprintf("%s %s is outdated. Latest version is %s", packageName, updateLevel, latestVersion)
+	if update.Error != nil {
+		testCase.Error = &JUnitError{
+			Message: fmt.Sprintf("fails to check %s", packageName),
+			Type:    "check-error",
+			Text:    update.Error.Error(),
+		}
+	} else if update.NotFound && update.Fatal {
+		msg := fmt.Sprintf("%s not found on any registry", packageName)
+
+		testCase.Failure = &JUnitFailure{
+			Message: msg,
+			Type:    "not-found",
+			Text:    msg,
+		}
+	} else if update.Confusion.IsCritical() && update.Fatal {
+		msg := fmt.Sprintf("%s found on several registries, with a higher public version: %s",
+			packageName, update.Confusion)
+
+		testCase.Failure = &JUnitFailure{
+			Message: msg,
+			Type:    "dependency-confusion",
+			Text:    msg,
+		}
+	} else if update.Fatal {
+		msg := fmt.Sprintf("%s %s is outdated. Latest version is %s", packageName, updateLevel, update.LatestVersion)
+
+		if update.LockedVersion != "" {
+			msg = fmt.Sprintf("%s %s is outdated (locked %s). Latest version is %s", packageName, updateLevel, update.LockedVersion, update.LatestVersion)
+		}
+
+		if update.InstallableVersion != update.LatestVersion {
+			msg = fmt.Sprintf("%s (installable %s)", msg, update.InstallableVersion)
+		}
+
+		msg += update.Notes()
 
 		testCase.Failure = &JUnitFailure{
 			Message: msg,
@@ -142,6 +225,15 @@ func (r *JUnitReporter) Report(
 		}
 	}
 
+	// Remarks about a package which doesn't fail (e.g. yanked pinned version)
+	if testCase.Failure == nil && testCase.Error == nil {
+		if update.NotFound {
+			testCase.SystemOut = fmt.Sprintf("%s not found on any registry", packageName)
+		} else if notes := update.Notes(); notes != "" {
+			testCase.SystemOut = packageName + notes
+		}
+	}
+
 	testSuite.TestCases = append(testSuite.TestCases, testCase)
 
 	return nil
@@ -155,12 +247,12 @@ func finalizeTestSuite(suite *JUnitTestSuite) {
 	suite.Timestamp = ts.Format("2006-01-02T15:04:05")
 
 	for _, testCase := range suite.TestCases {
-		if testCase.Failure != nil {
+		if testCase.Error != nil {
+			suite.Errors++
+		} else if testCase.Failure != nil {
 			suite.Failures++
 		} else if testCase.Skipped != nil {
 			suite.Skipped++
-		} else {
-			suite.Errors++
 		}
 	}
 }
@@ -169,14 +261,25 @@ func (r *JUnitReporter) After(out io.Writer) {
 	finalizeTestSuite(&r.DevTestSuite)
 	finalizeTestSuite(&r.RunTestSuite)
 
+	suites := []JUnitTestSuite{r.DevTestSuite, r.RunTestSuite}
+
+	for i := range r.GroupTestSuites {
+		finalizeTestSuite(&r.GroupTestSuites[i])
+
+		suites = append(suites, r.GroupTestSuites[i])
+	}
+
 	testSuites := testSuites{
-		Name:     fmt.Sprintf("wilf v%s", r.Version),
-		Tests:    r.DevTestSuite.Tests + r.RunTestSuite.Tests,
-		Failures: r.DevTestSuite.Failures + r.RunTestSuite.Failures,
-		Errors:   r.DevTestSuite.Errors + r.RunTestSuite.Errors,
-		Skipped:  r.DevTestSuite.Skipped + r.RunTestSuite.Skipped,
-		Time:     SecondsSince(r.StartTime),
-		Suites:   []JUnitTestSuite{r.DevTestSuite, r.RunTestSuite},
+		Name:   fmt.Sprintf("wilf v%s", r.Version),
+		Time:   SecondsSince(r.StartTime),
+		Suites: suites,
+	}
+
+	for _, suite := range suites {
+		testSuites.Tests += suite.Tests
+		testSuites.Failures += suite.Failures
+		testSuites.Errors += suite.Errors
+		testSuites.Skipped += suite.Skipped
 	}
 
 	xmlHeader := []byte(xml.Header)
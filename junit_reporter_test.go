@@ -2,7 +2,9 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -110,15 +112,17 @@ func TestReport(t *testing.T) {
 
 	// Call the Report function with some sample data
 	err := r.Report(
-		"mypackage",
-		VersionRequirement{{"<", "1.0.0"}},
-		"1.0.0",
-		Major,
-		RunDependency,
-		"https://mypackage.com",
-		false,
+		PackageUpdate{
+			PackageName:    "mypackage",
+			Requirement:    VersionRequirement{{"<", "1.0.0"}},
+			LatestVersion:  "1.0.0",
+			UpdateLevel:    Major,
+			DependencyKind: RunDependency,
+			PackageUrl:     "https://mypackage.com",
+			Fatal:          false,
+			TimeSec:        1.23,
+		},
 		[]string{},
-		1.23,
 		out,
 	)
 
@@ -187,7 +191,12 @@ func TestAfter(t *testing.T) {
 				{
 					Name:      "test3",
 					Timestamp: formattedTs,
-					Time:      3.0,
+					Error: &JUnitError{
+						Message: "fails to check test3",
+						Type:    "check-error",
+						Text:    "test3 error message",
+					},
+					Time: 3.0,
 				},
 				{
 					Name:      "test4",
@@ -210,15 +219,17 @@ func TestAfter(t *testing.T) {
 
 	// Check the output
 	expected := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
-<testsuites name="wilf v1.0.0" tests="4" failures="1" errors="2" skipped="1" time="5">
-  <testsuite name="dev" timestamp="%[1]s" tests="2" failures="1" errors="1" skipped="0" time="5">
+<testsuites name="wilf v1.0.0" tests="4" failures="1" errors="1" skipped="1" time="5">
+  <testsuite name="dev" timestamp="%[1]s" tests="2" failures="1" errors="0" skipped="0" time="5">
     <testcase name="test1" time="2" timestamp="%[1]s"></testcase>
     <testcase name="test2" time="3" timestamp="%[1]s">
       <failure message="test2 failed" type="error">test2 error message</failure>
     </testcase>
   </testsuite>
   <testsuite name="run" timestamp="%[1]s" tests="2" failures="0" errors="1" skipped="1" time="5">
-    <testcase name="test3" time="3" timestamp="%[1]s"></testcase>
+    <testcase name="test3" time="3" timestamp="%[1]s">
+      <error message="fails to check test3" type="check-error">test3 error message</error>
+    </testcase>
     <testcase name="test4" time="4" timestamp="%[1]s">
       <skipped message="test4 skipped">test4 skipped message</skipped>
     </testcase>
@@ -229,3 +240,244 @@ func TestAfter(t *testing.T) {
 		t.Errorf("unexpected output:\n%s", buf.String())
 	}
 }
+
+func TestReportGroupDependency(t *testing.T) {
+	r := &JUnitReporter{Version: "1.0.0"}
+	out := &bytes.Buffer{}
+
+	r.Before(out)
+
+	for _, pkg := range []string{"sphinx", "furo"} {
+		err := r.Report(
+			PackageUpdate{
+				PackageName:    pkg,
+				Requirement:    VersionRequirement{{">=", "7"}},
+				LatestVersion:  "8.0.0",
+				UpdateLevel:    Major,
+				DependencyKind: GroupDependency("docs"),
+				Fatal:          true,
+			},
+			[]string{},
+			out,
+		)
+
+		if err != nil {
+			t.Errorf("Report returned an error: %v", err)
+		}
+	}
+
+	if len(r.GroupTestSuites) != 1 || len(r.GroupTestSuites[0].TestCases) != 2 {
+		t.Fatalf("Expected a single group test suite with 2 test cases, got %+v", r.GroupTestSuites)
+	}
+
+	r.After(out)
+
+	if !strings.Contains(out.String(), `<testsuite name="docs"`) {
+		t.Errorf("Expected test suite for group 'docs':\n%s", out.String())
+	}
+
+	if !strings.Contains(out.String(), `tests="2" failures="2"`) {
+		t.Errorf("Expected failures to be counted:\n%s", out.String())
+	}
+}
+
+func TestReportGroupedByLevel(t *testing.T) {
+	r := &JUnitReporter{Version: "1.0.0"}
+	out := &bytes.Buffer{}
+
+	r.Before(out)
+
+	r.BeginGroup("major", out)
+
+	r.Report(PackageUpdate{
+		PackageName:    "sphinx",
+		UpdateLevel:    Major,
+		DependencyKind: DevDependency,
+		Fatal:          true,
+	}, []string{}, out)
+
+	r.BeginGroup("minor", out)
+
+	r.Report(PackageUpdate{
+		PackageName:    "furo",
+		UpdateLevel:    Minor,
+		DependencyKind: RunDependency,
+		Fatal:          true,
+	}, []string{}, out)
+
+	if len(r.DevTestSuite.TestCases) != 0 || len(r.RunTestSuite.TestCases) != 0 {
+		t.Errorf("Expected no test case in dev and run test suites")
+	}
+
+	if len(r.GroupTestSuites) != 2 ||
+		r.GroupTestSuites[0].Name != "major" || len(r.GroupTestSuites[0].TestCases) != 1 ||
+		r.GroupTestSuites[1].Name != "minor" || len(r.GroupTestSuites[1].TestCases) != 1 {
+		t.Errorf("Expected test suites per level, got %+v", r.GroupTestSuites)
+	}
+}
+
+func TestReportDependencyConfusion(t *testing.T) {
+	r := &JUnitReporter{Version: "1.0.0"}
+	out := &bytes.Buffer{}
+
+	r.Before(out)
+
+	registries := []RegistryVersion{
+		{Registry: "gitlab", Version: "v1.0.0"},
+		{Registry: "pypi", Version: "v99.0.0", Public: true},
+	}
+
+	for _, level := range []ConfusionLevel{ConfusionWarning, ConfusionCritical} {
+		r.Report(PackageUpdate{
+			PackageName:    level.String(),
+			DependencyKind: RunDependency,
+			Confusion:      &DependencyConfusion{Level: level, Registries: registries},
+			Fatal:          level == ConfusionCritical,
+		}, []string{}, out)
+	}
+
+	cases := r.RunTestSuite.TestCases
+
+	if len(cases) != 2 {
+		t.Fatalf("Expected 2 test cases, got %+v", cases)
+	}
+
+	if cases[0].Failure != nil || cases[0].SystemOut != "warning (dependency confusion: gitlab 1.0.0, pypi 99.0.0)" {
+		t.Errorf("Expected a warning for the non-critical confusion, got %+v", cases[0])
+	}
+
+	if f := cases[1].Failure; f == nil || f.Type != "dependency-confusion" || cases[1].SystemOut != "" {
+		t.Errorf("Expected a dependency-confusion failure, got %+v", cases[1])
+	}
+
+	r.After(out)
+
+	if !strings.Contains(out.String(), `<system-out>warning (dependency confusion: gitlab 1.0.0, pypi 99.0.0)`) {
+		t.Errorf("Expected the warning as system output:\n%s", out.String())
+	}
+}
+
+func TestReportCheckError(t *testing.T) {
+	r := &JUnitReporter{Version: "1.0.0"}
+	out := &bytes.Buffer{}
+
+	r.Before(out)
+
+	r.Report(PackageUpdate{
+		PackageName:    "requests",
+		DependencyKind: RunDependency,
+		Error:          errors.New("connection refused"),
+	}, []string{}, out)
+
+	cases := r.RunTestSuite.TestCases
+
+	if len(cases) != 1 {
+		t.Fatalf("Expected 1 test case, got %+v", cases)
+	}
+
+	if e := cases[0].Error; e == nil || e.Type != "check-error" || e.Text != "connection refused" || cases[0].Failure != nil {
+		t.Errorf("Expected a check error, got %+v", cases[0])
+	}
+
+	r.After(out)
+
+	if !strings.Contains(out.String(), `tests="1" failures="0" errors="1" skipped="0"`) {
+		t.Errorf("Expected the error to be counted:\n%s", out.String())
+	}
+
+	if !strings.Contains(out.String(), `<error message="fails to check requests" type="check-error">connection refused</error>`) {
+		t.Errorf("Expected the error element:\n%s", out.String())
+	}
+}
+
+func TestReportNotFound(t *testing.T) {
+	r := &JUnitReporter{Version: "1.0.0"}
+	out := &bytes.Buffer{}
+
+	r.Before(out)
+
+	for _, fatal := range []bool{false, true} {
+		r.Report(PackageUpdate{
+			PackageName:    fmt.Sprintf("fatal-%v", fatal),
+			DependencyKind: RunDependency,
+			NotFound:       true,
+			Fatal:          fatal,
+		}, []string{}, out)
+	}
+
+	cases := r.RunTestSuite.TestCases
+
+	if len(cases) != 2 {
+		t.Fatalf("Expected 2 test cases, got %+v", cases)
+	}
+
+	if cases[0].Failure != nil || cases[0].SystemOut != "fatal-false not found on any registry" {
+		t.Errorf("Expected a warning for the non-fatal package, got %+v", cases[0])
+	}
+
+	if f := cases[1].Failure; f == nil || f.Type != "not-found" || cases[1].SystemOut != "" {
+		t.Errorf("Expected a not-found failure, got %+v", cases[1])
+	}
+}
+
+func TestReportNotes(t *testing.T) {
+	r := &JUnitReporter{Version: "1.0.0"}
+	out := &bytes.Buffer{}
+
+	r.Before(out)
+
+	updates := []PackageUpdate{
+		{
+			PackageName:        "yanked",
+			LockedVersion:      "v1.0.0",
+			LatestVersion:      "v1.0.1",
+			InstallableVersion: "v1.0.1",
+			UpdateLevel:        Patch,
+			Yanked:             true,
+			YankedReason:       "broken build",
+		},
+		{
+			PackageName:        "prerelease",
+			LatestVersion:      "v2.1.0rc1",
+			InstallableVersion: "v2.1.0rc1",
+			UpdateLevel:        Minor,
+		},
+		{
+			PackageName:        "fatal",
+			LatestVersion:      "v2.0.0",
+			InstallableVersion: "v2.0.0",
+			UpdateLevel:        Major,
+			Yanked:             true,
+			Fatal:              true,
+		},
+		{
+			PackageName:        "uptodate",
+			LatestVersion:      "v1.0.0",
+			InstallableVersion: "v1.0.0",
+		},
+	}
+
+	for _, update := range updates {
+		update.DependencyKind = RunDependency
+
+		r.Report(update, []string{}, out)
+	}
+
+	cases := r.RunTestSuite.TestCases
+
+	if len(cases) != len(updates) {
+		t.Fatalf("Expected %d test cases, got %+v", len(updates), cases)
+	}
+
+	expected := []string{"yanked (yanked: broken build)", "prerelease (pre-release)", "", ""}
+
+	for i, testCase := range cases {
+		if testCase.SystemOut != expected[i] {
+			t.Errorf("Expected system output '%s' for %s, got '%s'", expected[i], testCase.Name, testCase.SystemOut)
+		}
+	}
+
+	if f := cases[2].Failure; f == nil || !strings.Contains(f.Message, "(yanked)") {
+		t.Errorf("Expected the notes in the failure, got %+v", cases[2])
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -20,19 +23,19 @@ func main() {
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		PrintUsage()
-		os.Exit(1)
+		exit(ExitUsageError)
 		return
 	}
 
 	if commandArgs.PrintUsage {
 		PrintUsage()
-		os.Exit(0)
+		exit(ExitOk)
 		return
 	}
 
 	if commandArgs.PrintVersion {
 		fmt.Println(version)
-		os.Exit(0)
+		exit(ExitOk)
 		return
 	}
 
@@ -52,7 +55,7 @@ func main() {
 				err.Error(),
 			)
 
-			os.Exit(2)
+			exit(ExitConfigError)
 
 			return
 		}
@@ -64,104 +67,313 @@ func main() {
 		settings = *config.Settings
 	}
 
-	file, err := os.Open(commandArgs.Pipfile)
+	if ci := LookupGitlabCIEnvironment(); ci != nil && !commandArgs.NoGitlabCI {
+		log.Debugf("Gitlab CI environment detected: %s", ci.ApiUrl)
+
+		if config == nil {
+			config = &Config{Settings: &settings}
+		}
+
+		config.Gitlab = ci.Configure(config.Gitlab)
+	}
+
+	content, err := os.ReadFile(commandArgs.Pipfile)
 
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "fails to open Pipfile '%s': %s",
+		fmt.Fprintf(os.Stderr, "fails to open dependency file '%s': %s",
 			commandArgs.Pipfile, err.Error())
 
-		os.Exit(3)
+		exit(ExitReadError)
 
 		return
 	}
 
-	defer file.Close()
+	format := DetectFileFormat(commandArgs.Pipfile)
+
+	log.Debugf("Loading %s file: %s", format, commandArgs.Pipfile)
+
+	var pipfile Pipfile
 
-	pipfile, err := ParsePipfile(file)
+	runtimeLocks := LockedVersions{}
+	devLocks := LockedVersions{}
+	groupLocks := LockedVersions{}
+
+	switch format {
+	case RequirementsFormat:
+		pipfile, err = ParseRequirementsFile(commandArgs.Pipfile)
+
+	case PyprojectFormat:
+		pipfile, err = ParsePyproject(bytes.NewReader(content))
+
+		if err == nil {
+			runtimeLocks, err = loadPoetryLock(
+				filepath.Join(filepath.Dir(commandArgs.Pipfile), "poetry.lock"),
+			)
+
+			devLocks = runtimeLocks
+			groupLocks = runtimeLocks
+		}
+
+	default:
+		pipfile, err = ParsePipfile(bytes.NewReader(content))
+
+		if err == nil {
+			runtimeLocks, devLocks, err = loadPipfileLock(
+				commandArgs.Pipfile+".lock",
+				content,
+			)
+		}
+	}
 
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(4)
+		exit(ExitParseError)
 		return
 	}
 
-	checker := CreateCompositeChecker(config, pipfile.RequiresPythonVersion)
+	prereleases := settings.Prereleases
+
+	if prereleases == 0 {
+		prereleases = DefaultPrereleasePolicy(pipfile.Pipenv.AllowPrereleases)
+	}
+
+	log.Debugf("Pre-release policy: %s", prereleases)
+
+	if commandArgs.Offline != "" && config != nil && len(config.Gitlab) > 0 {
+		for _, gitlabConfig := range config.Gitlab {
+			log.Warnf("Gitlab registry is not checked in offline mode: %s",
+				gitlabConfig.PackagesUrl())
+		}
 
-	reportUpdates := func(
+		config = &Config{Settings: config.Settings, Index: config.Index, Http: config.Http}
+	}
+
+	httpConfig := DefaultHttpConfig()
+
+	if config != nil && config.Http != nil {
+		httpConfig = *config.Http
+	}
+
+	clients, err := NewHttpClientFactory(httpConfig)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(ExitConfigError)
+		return
+	}
+
+	clients.CacheDir = settings.CacheDir
+	clients.CacheTTL = settings.CacheTTL
+	clients.NoCache = commandArgs.NoCache
+	clients.Offline = commandArgs.Offline
+	clients.Snapshot = commandArgs.Snapshot
+
+	log.Debugf("HTTP clients: %+v", clients)
+
+	checker, err := CreateCompositeChecker(config, pipfile, prereleases, clients)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(ExitConfigError)
+		return
+	}
+
+	checkUpdates := func(
 		dependencies Dependencies,
 		kind DependencyKind,
-	) (bool, error) {
-		globalFatal := false
-
-		for _, reporting := range reportings {
-			fatal, err := ReportUpdates(
-				dependencies,
-				kind,
-				settings.UpdateLevel,
-				settings.ExcludedPackages,
-				checker,
-				reporting.Reporter,
-				reporting.Output,
-			)
+		lockedVersions LockedVersions,
+	) ([]PackageUpdate, error) {
+		return CheckUpdates(
+			dependencies,
+			kind,
+			lockedVersions,
+			settings.FailPolicy(),
+			settings.Parallelism,
+			checker,
+		)
+	}
 
-			if err != nil {
-				return fatal, err
-			}
+	// When continuing on errors, whether some packages cannot be checked,
+	// to exit with ExitCheckError once everything is reported
+	continueOnError := settings.ContinueOnError || commandArgs.ContinueOnError
+	checkError := false
 
-			if fatal {
-				globalFatal = true
-			}
+	checkFailed := func(err error) bool {
+		if err == nil {
+			return false
 		}
 
-		return globalFatal, nil
-	}
+		if !continueOnError {
+			fmt.Fprintln(os.Stderr, err)
+			exit(ExitCheckError)
 
-	for _, reporting := range reportings {
-		reporting.Reporter.Before(reporting.Output)
+			return true
+		}
+
+		checkError = true
+
+		return false
 	}
 
 	log.Debugln("Checking runtime Dependencies ...")
 
-	requiresUpdates, err := reportUpdates(
+	updates, err := checkUpdates(
 		pipfile.RuntimeDependencies,
 		RunDependency,
+		runtimeLocks,
 	)
 
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(5)
+	if checkFailed(err) {
 		return
 	}
 
 	if settings.CheckDevPackages {
 		log.Debugln("Checking dev dependencies ...")
 
-		reqDevUpdates, err := reportUpdates(
+		devUpdates, err := checkUpdates(
 			pipfile.DevDependencies,
 			DevDependency,
+			devLocks,
 		)
 
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(6)
+		if checkFailed(err) {
 			return
 		}
 
-		if reqDevUpdates {
-			requiresUpdates = true
+		updates = append(updates, devUpdates...)
+	}
+
+	groups := make([]string, 0, len(pipfile.GroupDependencies))
+
+	for group := range pipfile.GroupDependencies {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		log.Debugf("Checking %s dependencies ...", group)
+
+		groupUpdates, err := checkUpdates(
+			pipfile.GroupDependencies[group],
+			GroupDependency(group),
+			groupLocks,
+		)
+
+		if checkFailed(err) {
+			return
 		}
+
+		updates = append(updates, groupUpdates...)
+	}
+
+	for _, reporting := range reportings {
+		reporting.Reporter.Before(reporting.Output)
 	}
 
+	requiresUpdates := ReportUpdates(
+		updates,
+		settings.ExcludedPackages,
+		settings.SortBy,
+		settings.GroupBy,
+		reportings,
+	)
+
 	for _, reporting := range reportings {
 		reporting.Reporter.After(reporting.Output)
 	}
 
+	if checkError {
+		for _, update := range updates {
+			if update.Error != nil {
+				fmt.Fprintln(os.Stderr, update.Error)
+			}
+		}
+
+		exit(ExitCheckError)
+		return
+	}
+
 	if !requiresUpdates {
 		log.Debugf("no updates required")
 
-		os.Exit(0)
+		exit(ExitOk)
 		return
 	}
 
-	os.Exit(5)
+	exit(ExitOutdated)
+}
+
+// loadPipfileLock loads the runtime and dev locked versions
+// from the Pipfile.lock at the given path, if it exists.
+// A warning is logged if the lock is out-of-date
+// regarding the content of the Pipfile.
+func loadPipfileLock(
+	path string,
+	pipfileContent []byte,
+) (LockedVersions, LockedVersions, error) {
+	file, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		log.Debugf("no Pipfile.lock found: %s", path)
+
+		return LockedVersions{}, LockedVersions{}, nil
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("fails to open Pipfile.lock '%s': %s", path, err.Error())
+	}
+
+	defer file.Close()
+
+	lock, err := ParsePipfileLock(file)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash, err := ComputePipfileHash(bytes.NewReader(pipfileContent))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !lock.IsUpToDate(hash) {
+		log.Warnf("Pipfile.lock is out-of-date (expected hash %s, found %s): %s",
+			hash, lock.Meta.Hash.Sha256, path)
+	}
+
+	runtimeLocks, err := lock.RuntimeVersions()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	devLocks, err := lock.DevVersions()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return runtimeLocks, devLocks, nil
+}
+
+// loadPoetryLock loads the locked versions
+// from the poetry.lock at the given path, if it exists.
+func loadPoetryLock(path string) (LockedVersions, error) {
+	file, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		log.Debugf("no poetry.lock found: %s", path)
+
+		return LockedVersions{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fails to open poetry.lock '%s': %s", path, err.Error())
+	}
+
+	defer file.Close()
+
+	return ParsePoetryLock(file)
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UpdateOrder indicates how the updates are sorted in the reports.
+type UpdateOrder string
+
+const (
+	// Sort by package name (alphabetically)
+	OrderByName UpdateOrder = "name"
+
+	// Sort by update level, from major to patch
+	OrderByLevel UpdateOrder = "level"
+
+	// Sort by dependency kind: runtime, then dev, then groups alphabetically
+	OrderByKind UpdateOrder = "kind"
+
+	// Sort by registry (alphabetically)
+	OrderByRegistry UpdateOrder = "registry"
+)
+
+// ParseUpdateOrder parses an UpdateOrder (`name`, `level`, `kind` or `registry`).
+// If the string representation is not valid, an error is returned.
+func ParseUpdateOrder(s string) (UpdateOrder, error) {
+	switch order := UpdateOrder(s); order {
+	case OrderByName, OrderByLevel, OrderByKind, OrderByRegistry:
+		return order, nil
+	}
+
+	return "", fmt.Errorf("invalid UpdateOrder: %s", s)
+}
+
+// UpdateGrouping indicates how the updates are grouped in the reports.
+type UpdateGrouping string
+
+const (
+	// No grouping
+	GroupByNone UpdateGrouping = ""
+
+	// Group by update level
+	GroupByLevel UpdateGrouping = "level"
+
+	// Group by dependency kind
+	GroupByKind UpdateGrouping = "kind"
+)
+
+// ParseUpdateGrouping parses an UpdateGrouping (`level` or `kind`, or empty for none).
+// If the string representation is not valid, an error is returned.
+func ParseUpdateGrouping(s string) (UpdateGrouping, error) {
+	switch grouping := UpdateGrouping(s); grouping {
+	case GroupByNone, GroupByLevel, GroupByKind:
+		return grouping, nil
+	}
+
+	return "", fmt.Errorf("invalid UpdateGrouping: %s", s)
+}
+
+// Group returns the name of the group for the update
+// (e.g. `major` when grouping by level), or an empty string if not grouping.
+func (g UpdateGrouping) Group(update PackageUpdate) string {
+	switch g {
+	case GroupByLevel:
+		return update.UpdateLevel.String()
+	case GroupByKind:
+		return update.DependencyKind.String()
+	}
+
+	return ""
+}
+
+// SortUpdates sorts the updates by group (if any), then according the order.
+// The updates which are equal according the order are sorted by package name,
+// then by dependency kind, so the result is deterministic.
+func SortUpdates(
+	updates []PackageUpdate,
+	order UpdateOrder,
+	grouping UpdateGrouping,
+) {
+	sort.SliceStable(updates, func(i, j int) bool {
+		a, b := updates[i], updates[j]
+
+		c := 0
+
+		switch grouping {
+		case GroupByLevel:
+			c = compareLevels(a, b)
+		case GroupByKind:
+			c = compareKinds(a, b)
+		}
+
+		if c == 0 {
+			switch order {
+			case OrderByLevel:
+				c = compareLevels(a, b)
+			case OrderByKind:
+				c = compareKinds(a, b)
+			case OrderByRegistry:
+				c = strings.Compare(a.Registry, b.Registry)
+			}
+		}
+
+		if c == 0 {
+			c = strings.Compare(a.PackageName, b.PackageName)
+		}
+
+		if c == 0 {
+			c = compareKinds(a, b)
+		}
+
+		return c < 0
+	})
+}
+
+// compareLevels compares the update levels, the highest one first.
+func compareLevels(a, b PackageUpdate) int {
+	return compareInts(int(b.UpdateLevel), int(a.UpdateLevel))
+}
+
+// compareKinds compares the dependency kinds: runtime, dev, then the groups.
+func compareKinds(a, b PackageUpdate) int {
+	rank := func(kind DependencyKind) int {
+		switch kind {
+		case RunDependency:
+			return 0
+		case DevDependency:
+			return 1
+		}
+
+		return 2
+	}
+
+	if c := compareInts(rank(a.DependencyKind), rank(b.DependencyKind)); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.DependencyKind.String(), b.DependencyKind.String())
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUpdateOrder(t *testing.T) {
+	for _, s := range []string{"name", "level", "kind", "registry"} {
+		order, err := ParseUpdateOrder(s)
+
+		if err != nil || string(order) != s {
+			t.Errorf("Expected order %s, but got %s (%v)", s, order, err)
+		}
+	}
+
+	if _, err := ParseUpdateOrder("size"); err == nil {
+		t.Errorf("Expected error for invalid order")
+	}
+}
+
+func TestParseUpdateGrouping(t *testing.T) {
+	for _, s := range []string{"", "level", "kind"} {
+		grouping, err := ParseUpdateGrouping(s)
+
+		if err != nil || string(grouping) != s {
+			t.Errorf("Expected grouping %s, but got %s (%v)", s, grouping, err)
+		}
+	}
+
+	if _, err := ParseUpdateGrouping("registry"); err == nil {
+		t.Errorf("Expected error for invalid grouping")
+	}
+}
+
+func TestSortUpdates(t *testing.T) {
+	updates := []PackageUpdate{
+		{PackageName: "lorem", Registry: "pypi", DependencyKind: DevDependency},
+		{PackageName: "ipsum", Registry: "gitlab", DependencyKind: RunDependency},
+		{PackageName: "lorem", Registry: "pypi", DependencyKind: RunDependency},
+		{PackageName: "dolor", Registry: "pypi", DependencyKind: RunDependency},
+	}
+
+	SortUpdates(updates, OrderByRegistry, GroupByNone)
+
+	type entry struct {
+		name string
+		kind DependencyKind
+	}
+
+	var actual []entry
+
+	for _, update := range updates {
+		actual = append(actual, entry{update.PackageName, update.DependencyKind})
+	}
+
+	expected := []entry{
+		{"ipsum", RunDependency},
+		{"dolor", RunDependency},
+		{"lorem", RunDependency},
+		{"lorem", DevDependency},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+
+	if g := GroupByLevel.Group(PackageUpdate{UpdateLevel: Minor}); g != "minor" {
+		t.Errorf("Expected group minor, but got %s", g)
+	}
+
+	if g := GroupByNone.Group(PackageUpdate{UpdateLevel: Minor}); g != "" {
+		t.Errorf("Expected no group, but got %s", g)
+	}
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version represents a version according PEP 440
+// (https://peps.python.org/pep-0440/),
+// e.g. `1!2.0.1rc1.post2.dev3+cpu`.
+type Version struct {
+	Epoch   int
+	Release []int
+
+	// Pre-release label (`a`, `b` or `rc`) and number, if PreLabel is not empty
+	PreLabel  string
+	PreNumber int
+
+	// Post-release number, if HasPost
+	HasPost    bool
+	PostNumber int
+
+	// Development release number, if HasDev
+	HasDev    bool
+	DevNumber int
+
+	// Local version label segments (e.g. `["ubuntu", "1"]` for `+ubuntu.1`)
+	Local []string
+}
+
+// See https://peps.python.org/pep-0440/#appendix-b-parsing-version-strings-with-regular-expressions
+var pep440Version = regexp.MustCompile(`(?i)^\s*v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_\.]?(?P<pre_l>alpha|a|beta|b|preview|pre|c|rc)[-_\.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:(?:-(?P<post_n1>[0-9]+))|(?:[-_\.]?(?P<post_l>post|rev|r)[-_\.]?(?P<post_n2>[0-9]+)?))?` +
+	`(?:[-_\.]?(?P<dev_l>dev)[-_\.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_\.][a-z0-9]+)*))?` +
+	`\s*$`)
+
+var localSeparators = regexp.MustCompile(`[-_\.]`)
+
+// ParseVersion parses a PEP 440 version (with an optional `v` prefix),
+// accepting the alternative spellings which are normalized
+// (e.g. `1.0-alpha.1` is parsed as `1.0a1`).
+func ParseVersion(s string) (Version, error) {
+	match := pep440Version.FindStringSubmatch(s)
+
+	if match == nil {
+		return Version{}, fmt.Errorf("invalid version: %s", s)
+	}
+
+	group := func(name string) string {
+		return match[pep440Version.SubexpIndex(name)]
+	}
+
+	number := func(s string) int {
+		if s == "" {
+			return 0
+		}
+
+		n, err := strconv.Atoi(s)
+
+		if err != nil {
+			// Only digits are matched, so it can only overflow
+			return int(^uint(0) >> 1)
+		}
+
+		return n
+	}
+
+	var v Version
+
+	v.Epoch = number(group("epoch"))
+
+	for _, segment := range strings.Split(group("release"), ".") {
+		v.Release = append(v.Release, number(segment))
+	}
+
+	if label := strings.ToLower(group("pre_l")); label != "" {
+		switch label {
+		case "alpha":
+			label = "a"
+		case "beta":
+			label = "b"
+		case "c", "pre", "preview":
+			label = "rc"
+		}
+
+		v.PreLabel = label
+		v.PreNumber = number(group("pre_n"))
+	}
+
+	if n := group("post_n1"); n != "" {
+		v.HasPost = true
+		v.PostNumber = number(n)
+	} else if group("post_l") != "" {
+		v.HasPost = true
+		v.PostNumber = number(group("post_n2"))
+	}
+
+	if group("dev_l") != "" {
+		v.HasDev = true
+		v.DevNumber = number(group("dev_n"))
+	}
+
+	if local := strings.ToLower(group("local")); local != "" {
+		v.Local = localSeparators.Split(local, -1)
+	}
+
+	return v, nil
+}
+
+// String returns the canonical form of the version
+// (without the `v` prefix).
+func (v Version) String() string {
+	var b strings.Builder
+
+	if v.Epoch != 0 {
+		fmt.Fprintf(&b, "%d!", v.Epoch)
+	}
+
+	for i, n := range v.Release {
+		if i > 0 {
+			b.WriteString(".")
+		}
+
+		b.WriteString(strconv.Itoa(n))
+	}
+
+	if v.PreLabel != "" {
+		fmt.Fprintf(&b, "%s%d", v.PreLabel, v.PreNumber)
+	}
+
+	if v.HasPost {
+		fmt.Fprintf(&b, ".post%d", v.PostNumber)
+	}
+
+	if v.HasDev {
+		fmt.Fprintf(&b, ".dev%d", v.DevNumber)
+	}
+
+	if len(v.Local) > 0 {
+		fmt.Fprintf(&b, "+%s", strings.Join(v.Local, "."))
+	}
+
+	return b.String()
+}
+
+// IsPreRelease returns true if the version is a pre-release
+// or a development release.
+func (v Version) IsPreRelease() bool {
+	return v.PreLabel != "" || v.HasDev
+}
+
+// Public returns the public version, without local version label.
+func (v Version) Public() Version {
+	v.Local = nil
+
+	return v
+}
+
+// Major returns the first segment of the release.
+func (v Version) Major() int {
+	return v.segment(0)
+}
+
+// Minor returns the second segment of the release (0 if missing).
+func (v Version) Minor() int {
+	return v.segment(1)
+}
+
+func (v Version) segment(i int) int {
+	if i < len(v.Release) {
+		return v.Release[i]
+	}
+
+	return 0
+}
+
+// Compare returns an integer comparing two versions according PEP 440 ordering:
+// 0 if v == o, -1 if v < o, and +1 if v > o.
+// Trailing zeros of the release are not significant (`1.0` == `1.0.0`),
+// and `1.0.dev1` < `1.0a1` < `1.0` < `1.0+local` < `1.0.post1`.
+func (v Version) Compare(o Version) int {
+	if c := compareInts(v.Epoch, o.Epoch); c != 0 {
+		return c
+	}
+
+	l := len(v.Release)
+
+	if len(o.Release) > l {
+		l = len(o.Release)
+	}
+
+	for i := 0; i < l; i++ {
+		if c := compareInts(v.segment(i), o.segment(i)); c != 0 {
+			return c
+		}
+	}
+
+	if c := compareInts(v.preRank(), o.preRank()); c != 0 {
+		return c
+	}
+
+	if v.PreLabel != "" {
+		if c := compareInts(v.PreNumber, o.PreNumber); c != 0 {
+			return c
+		}
+	}
+
+	if c := compareOptionalInts(v.HasPost, v.PostNumber, o.HasPost, o.PostNumber, false); c != 0 {
+		return c
+	}
+
+	if c := compareOptionalInts(v.HasDev, v.DevNumber, o.HasDev, o.DevNumber, true); c != 0 {
+		return c
+	}
+
+	return compareLocals(v.Local, o.Local)
+}
+
+// preRank ranks the pre-release phase:
+// a development release (without pre or post release) comes before alpha,
+// then beta, release candidate, and final release.
+func (v Version) preRank() int {
+	switch v.PreLabel {
+	case "a":
+		return 1
+	case "b":
+		return 2
+	case "rc":
+		return 3
+	}
+
+	if v.HasDev && !v.HasPost {
+		return 0
+	}
+
+	return 4
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+
+	if a > b {
+		return 1
+	}
+
+	return 0
+}
+
+// compareOptionalInts compares two optional numbers,
+// a missing one being either the greatest (if missingGreatest is true)
+// or the lowest one.
+func compareOptionalInts(
+	hasA bool, a int,
+	hasB bool, b int,
+	missingGreatest bool,
+) int {
+	if hasA && hasB {
+		return compareInts(a, b)
+	}
+
+	if hasA == hasB {
+		return 0
+	}
+
+	if hasA == missingGreatest {
+		return -1
+	}
+
+	return 1
+}
+
+// compareLocals compares local version labels:
+// no label is lower than any label, numeric segments are greater
+// than alphanumeric ones, and a longer label is greater
+// if the shorter one is its prefix.
+func compareLocals(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+
+		var c int
+
+		if errA == nil && errB == nil {
+			c = compareInts(na, nb)
+		} else if errA == nil {
+			c = 1
+		} else if errB == nil {
+			c = -1
+		} else {
+			c = strings.Compare(a[i], b[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareInts(len(a), len(b))
+}
+
+// MatchSpecifier checks whether the version matches a PEP 440 version specifier
+// (https://peps.python.org/pep-0440/#version-specifiers),
+// given as an operator and a version (e.g. `~=` and `2.2`).
+// The `==` and `!=` operators support prefix matching (e.g. `1.2.*`).
+// It returns an error if the specifier is not valid.
+func (v Version) MatchSpecifier(op string, spec string) (bool, error) {
+	if op == "===" {
+		// Arbitrary equality
+		return strings.EqualFold(strings.TrimPrefix(spec, "v"), v.String()), nil
+	}
+
+	if op == "==" || op == "!=" {
+		matches, err := v.matchEquality(spec)
+
+		if op == "==" {
+			return matches, err
+		}
+
+		return !matches, err
+	}
+
+	if strings.HasSuffix(spec, ".*") {
+		return false, fmt.Errorf("invalid version matching: %s%s", op, spec)
+	}
+
+	sv, err := ParseVersion(spec)
+
+	if err != nil {
+		return false, err
+	}
+
+	switch op {
+	case "~=":
+		// See https://peps.python.org/pep-0440/#compatible-release
+		if len(sv.Release) < 2 {
+			return false, fmt.Errorf("invalid compatible release: %s%s", op, spec)
+		}
+
+		prefix := Version{
+			Epoch:   sv.Epoch,
+			Release: sv.Release[:len(sv.Release)-1],
+		}
+
+		return v.Public().Compare(sv) >= 0 && v.hasPrefix(prefix), nil
+
+	case "<=":
+		return v.Public().Compare(sv) <= 0, nil
+
+	case ">=":
+		return v.Public().Compare(sv) >= 0, nil
+
+	case "<":
+		// A pre-release of the specified version is excluded,
+		// unless the specified version is itself a pre-release
+		if v.Public().Compare(sv) >= 0 {
+			return false, nil
+		}
+
+		return sv.IsPreRelease() || !v.IsPreRelease() ||
+			!v.hasSameRelease(sv), nil
+
+	case ">":
+		// A post-release or a local version of the specified version is excluded,
+		// unless the specified version is itself a post-release
+		if v.Compare(sv) <= 0 {
+			return false, nil
+		}
+
+		if !sv.HasPost && v.HasPost && v.hasSameRelease(sv) {
+			return false, nil
+		}
+
+		return len(v.Local) == 0 || v.Public().Compare(sv) != 0, nil
+	}
+
+	return false, fmt.Errorf("invalid operator: %s", op)
+}
+
+// matchEquality checks the version against a `==` specifier,
+// either exact or as prefix (e.g. `1.2.*`).
+func (v Version) matchEquality(spec string) (bool, error) {
+	if prefix := strings.TrimSuffix(spec, ".*"); prefix != spec {
+		pv, err := ParseVersion(prefix)
+
+		if err != nil {
+			return false, err
+		}
+
+		if pv.PreLabel != "" || pv.HasPost || pv.HasDev || len(pv.Local) > 0 {
+			return false, fmt.Errorf("invalid version matching: %s", spec)
+		}
+
+		return v.hasPrefix(pv), nil
+	}
+
+	sv, err := ParseVersion(spec)
+
+	if err != nil {
+		return false, err
+	}
+
+	if len(sv.Local) == 0 {
+		// Local version of the candidate is ignored
+		return v.Public().Compare(sv) == 0, nil
+	}
+
+	return v.Compare(sv) == 0, nil
+}
+
+// hasPrefix checks whether the version release starts with the prefix release
+// (for the same epoch), with zero padding (`1.0` has the prefix `1.0.0`).
+func (v Version) hasPrefix(prefix Version) bool {
+	if v.Epoch != prefix.Epoch {
+		return false
+	}
+
+	for i, n := range prefix.Release {
+		if v.segment(i) != n {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isFinalRelease checks whether the version only consists of an epoch
+// and release segments (without pre, post, dev or local parts).
+func (v Version) isFinalRelease() bool {
+	return v.PreLabel == "" && !v.HasPost && !v.HasDev && len(v.Local) == 0
+}
+
+// hasSameRelease checks whether both versions have the same epoch and release.
+func (v Version) hasSameRelease(o Version) bool {
+	return Version{Epoch: v.Epoch, Release: v.Release}.Compare(
+		Version{Epoch: o.Epoch, Release: o.Release}) == 0
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  Version
+		canonical string
+	}{
+		{"1.2.3", Version{Release: []int{1, 2, 3}}, "1.2.3"},
+		{"v1.2", Version{Release: []int{1, 2}}, "1.2"},
+		{"1!2.0", Version{Epoch: 1, Release: []int{2, 0}}, "1!2.0"},
+		{
+			"1.0-ALPHA.1",
+			Version{Release: []int{1, 0}, PreLabel: "a", PreNumber: 1},
+			"1.0a1",
+		},
+		{
+			"1.0b",
+			Version{Release: []int{1, 0}, PreLabel: "b"},
+			"1.0b0",
+		},
+		{
+			"1.0c2",
+			Version{Release: []int{1, 0}, PreLabel: "rc", PreNumber: 2},
+			"1.0rc2",
+		},
+		{
+			"1.0-1",
+			Version{Release: []int{1, 0}, HasPost: true, PostNumber: 1},
+			"1.0.post1",
+		},
+		{
+			"1.0rev",
+			Version{Release: []int{1, 0}, HasPost: true},
+			"1.0.post0",
+		},
+		{
+			"1.0.dev",
+			Version{Release: []int{1, 0}, HasDev: true},
+			"1.0.dev0",
+		},
+		{
+			"1.0rc1.post2.dev3+Ubuntu-1",
+			Version{
+				Release:    []int{1, 0},
+				PreLabel:   "rc",
+				PreNumber:  1,
+				HasPost:    true,
+				PostNumber: 2,
+				HasDev:     true,
+				DevNumber:  3,
+				Local:      []string{"ubuntu", "1"},
+			},
+			"1.0rc1.post2.dev3+ubuntu.1",
+		},
+	}
+
+	for _, test := range tests {
+		v, err := ParseVersion(test.input)
+
+		if err != nil {
+			t.Errorf("Unexpected error for '%s': %s", test.input, err.Error())
+			continue
+		}
+
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("For '%s', expected %#v, but got %#v", test.input, test.expected, v)
+		}
+
+		if v.String() != test.canonical {
+			t.Errorf("For '%s', expected canonical form '%s', but got '%s'",
+				test.input, test.canonical, v.String())
+		}
+	}
+
+	for _, invalid := range []string{"", "1.", "1.*", "a.b", "1.0+", "1.0.0-x.7"} {
+		if _, err := ParseVersion(invalid); err == nil {
+			t.Errorf("Expected error for invalid version '%s'", invalid)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Ascending order
+	ordered := []string{
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc.5",
+		"1.0+abc.7",
+		"1.0+5",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.0.15",
+		"1.1.dev1",
+		"2013.10",
+		"1!0.1",
+	}
+
+	for i := range ordered {
+		a, err := ParseVersion(ordered[i])
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		for j := range ordered {
+			b, _ := ParseVersion(ordered[j])
+			expected := compareInts(i, j)
+
+			if c := a.Compare(b); c != expected {
+				t.Errorf("Comparing '%s' with '%s', expected %d, but got %d",
+					ordered[i], ordered[j], expected, c)
+			}
+		}
+	}
+
+	a, _ := ParseVersion("1.0")
+	b, _ := ParseVersion("1.0.0")
+
+	if a.Compare(b) != 0 {
+		t.Errorf("Expected '1.0' to be equal to '1.0.0'")
+	}
+}
+
+func TestMatchSpecifier(t *testing.T) {
+	tests := []struct {
+		version  string
+		op       string
+		spec     string
+		expected bool
+	}{
+		{"2.2", "~=", "2.2", true},
+		{"2.9.1", "~=", "2.2", true},
+		{"3.0", "~=", "2.2", false},
+		{"1.4.5", "~=", "1.4.5", true},
+		{"1.4.9", "~=", "1.4.5", true},
+		{"1.5.0", "~=", "1.4.5", false},
+		{"1.4.4", "~=", "1.4.5", false},
+		{"1.1.post1", "==", "1.1", false},
+		{"1.1", "==", "1.1.0", true},
+		{"1.1+local", "==", "1.1", true},
+		{"1.1", "==", "1.1+local", false},
+		{"1.1.3", "==", "1.1.*", true},
+		{"1.1", "==", "1.1.*", true},
+		{"1.1a1", "==", "1.1.*", true},
+		{"1.2", "==", "1.1.*", false},
+		{"1.2", "!=", "1.1.*", true},
+		{"1.1.post1", "!=", "1.1", true},
+		{"1.7", "<", "1.7", false},
+		{"1.7rc1", "<", "1.7", false},
+		{"1.7rc1", "<", "1.7rc2", true},
+		{"1.6.9", "<", "1.7", true},
+		{"1.7", "<=", "1.7", true},
+		{"1.7.post1", ">", "1.7", false},
+		{"1.7.1", ">", "1.7", true},
+		{"1.7+local", ">", "1.7", false},
+		{"1.7.post2", ">", "1.7.post1", true},
+		{"1.7+local", ">=", "1.7", true},
+		{"1.0+downstream1", "===", "1.0+downstream1", true},
+		{"1.0", "===", "1.0.0", false},
+	}
+
+	for _, test := range tests {
+		v, err := ParseVersion(test.version)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		matches, err := v.MatchSpecifier(test.op, test.spec)
+
+		if err != nil {
+			t.Errorf("Unexpected error for '%s%s': %s", test.op, test.spec, err.Error())
+			continue
+		}
+
+		if matches != test.expected {
+			t.Errorf("For '%s' against '%s%s', expected %v, but got %v",
+				test.version, test.op, test.spec, test.expected, matches)
+		}
+	}
+
+	invalid := [][2]string{
+		{"~=", "1"},
+		{">=", "1.*"},
+		{"==", "1.0a1.*"},
+		{"<>", "1.0"},
+	}
+
+	v, _ := ParseVersion("1.0")
+
+	for _, spec := range invalid {
+		if _, err := v.MatchSpecifier(spec[0], spec[1]); err == nil {
+			t.Errorf("Expected error for specifier '%s%s'", spec[0], spec[1])
+		}
+	}
+}
@@ -1,176 +1,486 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"io"
-	"regexp"
 	"strings"
 
 	"github.com/BurntSushi/toml"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/mod/semver"
 )
 
 type VersionConstraint = [2]string
 type VersionRequirement = []VersionConstraint
 type Dependencies = map[string]VersionRequirement
 
+// PipfileSource represents a `[[source]]` entry of a Pipfile.
+type PipfileSource struct {
+	Name      string `json:"name"`
+	Url       string `json:"url"`
+	VerifySsl bool   `json:"verify_ssl"`
+
+	// Whether the source is only used for the packages
+	// explicitly declared with this index (e.g. Poetry `explicit` sources)
+	Explicit bool `json:"-"`
+}
+
+// PipfilePackage represents the specification of a package,
+// as declared in the `[packages]` or `[dev-packages]` section of a Pipfile.
+// A package can be declared either as a simple version string
+// (e.g. `requests = "==2.31.0"`) or as an inline table
+// (e.g. `pymongo = {version = "==4.4.0", extras = ["srv"]}`).
+type PipfilePackage struct {
+	Version  string
+	Extras   []string
+	Markers  string
+	Index    string
+	Git      string
+	Ref      string
+	Path     string
+	File     string
+	Editable bool
+}
+
+// PipfileRequires represents the `[requires]` section of a Pipfile.
+type PipfileRequires struct {
+	PythonVersion     string `json:"python_version"`
+	PythonFullVersion string `json:"python_full_version"`
+}
+
+// PipfilePipenv represents the `[pipenv]` section of a Pipfile.
+type PipfilePipenv struct {
+	AllowPrereleases bool
+
+	// Whether the packages without declared index are looked up
+	// on all the sources, rather than only on the first one
+	InstallSearchAllSources bool
+}
+
 type Pipfile struct {
+	Sources     []PipfileSource
+	Packages    map[string]PipfilePackage
+	DevPackages map[string]PipfilePackage
+	Requires    PipfileRequires
+	Pipenv      PipfilePipenv
+
+	// Checkable requirements, resolved from the package specifications
 	RuntimeDependencies   Dependencies
 	DevDependencies       Dependencies
 	RequiresPythonVersion VersionRequirement
+
+	// Checkable requirements for the named groups
+	// (e.g. PEP 621 optional dependencies), if any
+	GroupDependencies map[string]Dependencies
+
+	// Package specifications for the named groups, if any
+	GroupPackages map[string]map[string]PipfilePackage
 }
 
+// ParsePipfile decodes a Pipfile from the given reader.
+// The TOML document is decoded as a whole, so that multi-line inline tables,
+// comments or `#` inside quoted strings (e.g. in `markers`)
+// and `[[source]]` arrays are properly supported.
+//
+// Packages declared without version (e.g. with `path`, `file` or `git`)
+// are kept in the model, but are not part of the checkable dependencies.
 func ParsePipfile(reader io.Reader) (Pipfile, error) {
+	var document map[string]interface{}
+
+	if _, err := toml.NewDecoder(reader).Decode(&document); err != nil {
+		return Pipfile{}, err
+	}
+
 	pipfile := Pipfile{
+		Sources:               []PipfileSource{},
+		Packages:              make(map[string]PipfilePackage),
+		DevPackages:           make(map[string]PipfilePackage),
 		RuntimeDependencies:   make(Dependencies),
 		DevDependencies:       make(Dependencies),
 		RequiresPythonVersion: VersionRequirement{},
 	}
 
-	scanner := bufio.NewScanner(reader)
-	var currentSection string
+	for section, value := range document {
+		var err error
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = strings.TrimSpace(line)
+		switch section {
+		case "source":
+			pipfile.Sources, err = parsePipfileSources(value)
 
-		// Check for comment and remove it
-		if commentIndex := strings.Index(line, "#"); commentIndex != -1 {
-			line = line[:commentIndex]
-		}
+		case "packages":
+			pipfile.Packages, err = parsePipfilePackages(section, value)
 
-		// Check for empty line or comment
-		if line == "" {
-			continue
+		case "dev-packages":
+			pipfile.DevPackages, err = parsePipfilePackages(section, value)
+
+		case "requires":
+			pipfile.Requires, err = parsePipfileRequires(value)
+
+		case "pipenv":
+			pipfile.Pipenv, err = parsePipfilePipenv(value)
+
+		default:
+			log.Debugf("Ignoring unknown section '%s'\n", section)
 		}
 
-		// Check for section header
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			currentSection = strings.ToLower(line[1 : len(line)-1])
-			continue
+		if err != nil {
+			return Pipfile{}, err
 		}
+	}
 
-		if currentSection == "[source]" {
-			continue
+	// ---
+
+	pythonVersion := pipfile.Requires.PythonVersion
+
+	if pythonVersion == "" {
+		pythonVersion = pipfile.Requires.PythonFullVersion
+	} else if IsValidVersion(pythonVersion) {
+		// Only the major and minor Python versions (e.g. `3.11` for any `3.11.*`)
+		pythonVersion = fmt.Sprintf("==%s.*", pythonVersion)
+	}
+
+	if pythonVersion != "" {
+		versionReq, err := ParseVersionRequirement(pythonVersion)
+
+		if err != nil {
+			return Pipfile{}, err
 		}
 
-		// Parse key-value pairs
-		parts := strings.SplitN(line, "=", 2)
+		pipfile.RequiresPythonVersion = versionReq
+	}
+
+	var err error
+
+	pipfile.RuntimeDependencies, err = pipfileDependencies(pipfile.Packages)
 
-		if len(parts) != 2 {
-			return Pipfile{}, fmt.Errorf("invalid line format: %s", line)
+	if err != nil {
+		return Pipfile{}, err
+	}
+
+	pipfile.DevDependencies, err = pipfileDependencies(pipfile.DevPackages)
+
+	if err != nil {
+		return Pipfile{}, err
+	}
+
+	return pipfile, nil
+}
+
+// UnmarshalTOML decodes a package specification,
+// either from a version string or from an inline table.
+func (p *PipfilePackage) UnmarshalTOML(data interface{}) error {
+	if version, ok := data.(string); ok {
+		*p = PipfilePackage{Version: version}
+
+		return nil
+	}
+
+	table, ok := data.(map[string]interface{})
+
+	if !ok {
+		return fmt.Errorf("invalid package specification: %v", data)
+	}
+
+	var spec PipfilePackage
+	var err error
+
+	for key, value := range table {
+		switch key {
+		case "version":
+			spec.Version, err = tomlString(key, value)
+
+		case "extras":
+			spec.Extras, err = tomlStrings(key, value)
+
+		case "markers":
+			spec.Markers, err = tomlString(key, value)
+
+		case "index":
+			spec.Index, err = tomlString(key, value)
+
+		case "git":
+			spec.Git, err = tomlString(key, value)
+
+		case "ref":
+			spec.Ref, err = tomlString(key, value)
+
+		case "path":
+			spec.Path, err = tomlString(key, value)
+
+		case "file":
+			spec.File, err = tomlString(key, value)
+
+		case "editable":
+			spec.Editable, err = tomlBool(key, value)
+
+		default:
+			log.Debugf("Ignoring unknown package field '%s'", key)
 		}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+		if err != nil {
+			return err
+		}
+	}
 
-		if currentSection == "requires" {
-			if key == "python_version" {
-				value = strings.Trim(value, `"'`)
-				versionReq, err := ParseVersionRequirement(value)
+	*p = spec
 
-				if err != nil {
-					return Pipfile{}, err
-				}
+	return nil
+}
 
-				pipfile.RequiresPythonVersion = versionReq
+// PackageIndexes returns the name of the index (source) declared
+// for each package (e.g. `index = "private"`), by normalized package name.
+func (p Pipfile) PackageIndexes() map[string]string {
+	indexes := make(map[string]string)
 
-				continue
+	packages := []map[string]PipfilePackage{p.Packages, p.DevPackages}
+
+	for _, group := range p.GroupPackages {
+		packages = append(packages, group)
+	}
+
+	for _, specs := range packages {
+		for name, spec := range specs {
+			if spec.Index != "" {
+				indexes[NormalizePackageName(name)] = spec.Index
 			}
 		}
+	}
+
+	return indexes
+}
 
-		if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
-			// Value is a dictionary, parse it
-			dict, err := ParsePythonDict(value)
+// DefaultSources returns the sources where the packages without declared index
+// are looked up: the first non-explicit source, or all of them
+// if `install_search_all_sources` is enabled.
+// If no source is declared, an empty slice is returned.
+func (p Pipfile) DefaultSources() []PipfileSource {
+	var sources []PipfileSource
 
-			if err != nil {
-				return Pipfile{}, fmt.Errorf("error parsing dictionary: %v: %s", err, value)
+	for _, source := range p.Sources {
+		if source.Explicit {
+			continue
+		}
+
+		sources = append(sources, source)
+
+		if !p.Pipenv.InstallSearchAllSources {
+			break
+		}
+	}
+
+	return sources
+}
+
+// IsLocal returns true if the package is not resolved from an index,
+// but from a VCS repository, a local path or a file.
+func (p PipfilePackage) IsLocal() bool {
+	return p.Git != "" || p.Path != "" || p.File != ""
+}
+
+func parsePipfileSources(value interface{}) ([]PipfileSource, error) {
+	tables, ok := value.([]map[string]interface{})
+
+	if !ok {
+		return nil, fmt.Errorf("invalid [[source]] section: %v", value)
+	}
+
+	sources := make([]PipfileSource, len(tables))
+
+	for i, table := range tables {
+		source := PipfileSource{VerifySsl: true}
+		var err error
+
+		for key, v := range table {
+			switch key {
+			case "name":
+				source.Name, err = tomlString(key, v)
+
+			case "url":
+				source.Url, err = tomlString(key, v)
+
+			case "verify_ssl":
+				source.VerifySsl, err = tomlBool(key, v)
+
+			default:
+				log.Debugf("Ignoring unknown source field '%s'", key)
 			}
 
-			if version, ok := dict["version"]; ok {
-				value = version
-			} else if _, ok := dict["path"]; ok {
-				// Ignore missing version if path is set to "."
-				continue
-			} else {
-				return Pipfile{}, fmt.Errorf("Field 'version' not found in specification: %s", key)
+			if err != nil {
+				return nil, err
 			}
-		} else {
-			// Remove surrounding quotes from the value
-			value = strings.Trim(value, `"'`)
 		}
 
-		versionReq, err := ParseVersionRequirement(value)
+		sources[i] = source
+	}
 
-		if err != nil {
-			return Pipfile{}, err
+	return sources, nil
+}
+
+func parsePipfilePackages(
+	section string,
+	value interface{},
+) (map[string]PipfilePackage, error) {
+	table, ok := value.(map[string]interface{})
+
+	if !ok {
+		return nil, fmt.Errorf("invalid [%s] section: %v", section, value)
+	}
+
+	packages := make(map[string]PipfilePackage, len(table))
+
+	for name, spec := range table {
+		var pkg PipfilePackage
+
+		if err := pkg.UnmarshalTOML(spec); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
 		}
 
-		switch currentSection {
-		case "packages":
-			pipfile.RuntimeDependencies[key] = versionReq
-		case "dev-packages":
-			pipfile.DevDependencies[key] = versionReq
+		packages[name] = pkg
+	}
+
+	return packages, nil
+}
+
+func parsePipfileRequires(value interface{}) (PipfileRequires, error) {
+	table, ok := value.(map[string]interface{})
+
+	if !ok {
+		return PipfileRequires{}, fmt.Errorf("invalid [requires] section: %v", value)
+	}
+
+	var requires PipfileRequires
+	var err error
+
+	for key, v := range table {
+		switch key {
+		case "python_version":
+			requires.PythonVersion, err = tomlString(key, v)
+
+		case "python_full_version":
+			requires.PythonFullVersion, err = tomlString(key, v)
+
 		default:
-			log.Debugf("Ignoring unknown section '%s'\n", currentSection)
+			log.Debugf("Ignoring unknown requirement '%s'", key)
+		}
+
+		if err != nil {
+			return PipfileRequires{}, err
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return Pipfile{}, err
+	return requires, nil
+}
+
+func parsePipfilePipenv(value interface{}) (PipfilePipenv, error) {
+	table, ok := value.(map[string]interface{})
+
+	if !ok {
+		return PipfilePipenv{}, fmt.Errorf("invalid [pipenv] section: %v", value)
 	}
 
-	return pipfile, nil
+	var pipenv PipfilePipenv
+	var err error
+
+	for key, v := range table {
+		switch key {
+		case "allow_prereleases":
+			pipenv.AllowPrereleases, err = tomlBool(key, v)
+
+		case "install_search_all_sources":
+			pipenv.InstallSearchAllSources, err = tomlBool(key, v)
+
+		default:
+			log.Debugf("Ignoring unknown pipenv setting '%s'", key)
+		}
+
+		if err != nil {
+			return PipfilePipenv{}, err
+		}
+	}
+
+	return pipenv, nil
 }
 
-func ParsePythonDict(input string) (map[string]string, error) {
-	chars := []rune(input[1 : len(input)-1])
-
-	inQuote := false
-	inArray := false
-
-	for i, ch := range chars {
-		if ch == '\'' || ch == '"' {
-			inQuote = !inQuote
-		} else if ch == '[' && !inArray {
-			inArray = true
-		} else if ch == ']' && inArray {
-			inArray = false
-		} else if !inQuote && !inArray && ch == ',' {
-			if i+1 < len(chars) {
-				chars[i] = '\r'
-				chars[i+1] = '\n'
-			} else {
-				chars[i] = '\n'
+// pipfileDependencies resolves the version requirements
+// of the given package specifications.
+// A package declared without version, and not resolved locally,
+// is considered as accepting any version (`*`).
+func pipfileDependencies(
+	packages map[string]PipfilePackage,
+) (Dependencies, error) {
+	dependencies := make(Dependencies)
+
+	for name, pkg := range packages {
+		version := pkg.Version
+
+		if version == "" {
+			if pkg.IsLocal() {
+				log.Debugf("Ignoring local package '%s'", name)
+
+				continue
 			}
+
+			version = "*"
 		}
+
+		versionReq, err := ParseVersionRequirement(version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		dependencies[name] = versionReq
 	}
 
-	input = string(chars)
+	return dependencies, nil
+}
 
-	result := make(map[string]interface{})
+func tomlString(key string, value interface{}) (string, error) {
+	s, ok := value.(string)
 
-	// Unmarshal the input using yaml
-	_, err := toml.Decode(input, &result)
+	if !ok {
+		return "", fmt.Errorf("field '%s' must be a string: %v", key, value)
+	}
 
-	if err != nil {
-		return nil, err
+	return s, nil
+}
+
+func tomlStrings(key string, value interface{}) ([]string, error) {
+	values, ok := value.([]interface{})
+
+	if !ok {
+		return nil, fmt.Errorf("field '%s' must be an array: %v", key, value)
 	}
 
-	dict := make(map[string]string)
+	strs := make([]string, len(values))
 
-	for key, val := range result {
-		dict[key] = fmt.Sprintf("%v", val)
+	for i, v := range values {
+		s, err := tomlString(key, v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		strs[i] = s
+	}
+
+	return strs, nil
+}
+
+func tomlBool(key string, value interface{}) (bool, error) {
+	b, ok := value.(bool)
+
+	if !ok {
+		return false, fmt.Errorf("field '%s' must be a boolean: %v", key, value)
 	}
 
-	return dict, nil
+	return b, nil
 }
 
-// TODO: Aside from '*' support V.*
+// ParseVersionRequirement parses a PEP 440 version requirement
+// (e.g. `>=1.21.0, <1.22.0`), as a list of constraints.
+// The Poetry requirements are parsed by ParsePoetryRequirement.
 func ParseVersionRequirement(input string) (VersionRequirement, error) {
-	operators := []string{"<=", "<", "!=", "==", ">=", ">", "~=", "==="}
+	operators := []string{"===", "<=", "<", "!=", "==", ">=", ">", "~="}
 	var verReq VersionRequirement
 
 NEXT_SPEC:
@@ -190,14 +500,17 @@ NEXT_SPEC:
 					return VersionRequirement{}, fmt.Errorf("missing version: %s", spec)
 				}
 
+				remaining = strings.TrimSpace(remaining)
 				ver := fmt.Sprintf("v%s", remaining)
 
-				n := strings.ReplaceAll(ver, "*", "0")
+				if operator == "===" {
+					// Arbitrary equality, not necessarily a valid version
+				} else if prefix := strings.TrimSuffix(remaining, ".*"); prefix != remaining {
+					// Version matching, only on release segments (e.g. `1.2.*`)
+					pv, err := ParseVersion(prefix)
 
-				if n != ver {
-					// Was normalized as matching expr
-					if !IsValidVersion(n) {
-						return VersionRequirement{}, fmt.Errorf("invalid version: %s", spec)
+					if err != nil || !pv.isFinalRelease() {
+						return VersionRequirement{}, fmt.Errorf("invalid version: %s", remaining)
 					}
 
 					if operator == "==" {
@@ -209,6 +522,8 @@ NEXT_SPEC:
 					}
 				} else if !IsValidVersion(ver) {
 					return VersionRequirement{}, fmt.Errorf("invalid version: %s", remaining)
+				} else if operator == "~=" && !strings.Contains(remaining, ".") {
+					return VersionRequirement{}, fmt.Errorf("invalid compatible release: %s", spec)
 				}
 
 				verReq = append(
@@ -232,12 +547,10 @@ NEXT_SPEC:
 	return verReq, nil
 }
 
+// IsValidVersion checks whether the given string is a valid PEP 440 version
+// (see ParseVersion).
 func IsValidVersion(version string) bool {
-	// Check non-standard version like zstd 1.5.5.1 (https://pypi.org/project/zstd/)
-	// which is not supported by semver
-	if match, _ := regexp.MatchString(`^v\d+\.\d+\.\d+(\.\d+)*$`, version); match {
-		return true
-	}
+	_, err := ParseVersion(version)
 
-	return semver.IsValid(version)
+	return err == nil
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LockedVersions maps the normalized name of a package to its locked version.
+type LockedVersions = map[string]string
+
+// PipfileLock represents a Pipfile.lock, as generated by Pipenv.
+type PipfileLock struct {
+	Meta    PipfileLockMeta          `json:"_meta"`
+	Default map[string]LockedPackage `json:"default"`
+	Develop map[string]LockedPackage `json:"develop"`
+}
+
+// PipfileLockMeta represents the `_meta` section of a Pipfile.lock.
+type PipfileLockMeta struct {
+	Hash struct {
+		Sha256 string `json:"sha256"`
+	} `json:"hash"`
+	PipfileSpec int             `json:"pipfile-spec"`
+	Requires    PipfileRequires `json:"requires"`
+	Sources     []PipfileSource `json:"sources"`
+}
+
+// LockedPackage represents a package entry
+// in the `default` or `develop` section of a Pipfile.lock.
+type LockedPackage struct {
+	Version  string   `json:"version"`
+	Hashes   []string `json:"hashes"`
+	Markers  string   `json:"markers"`
+	Index    string   `json:"index"`
+	Extras   []string `json:"extras"`
+	Git      string   `json:"git"`
+	Ref      string   `json:"ref"`
+	Path     string   `json:"path"`
+	File     string   `json:"file"`
+	Editable bool     `json:"editable"`
+}
+
+// ParsePipfileLock decodes a Pipfile.lock from the given reader.
+func ParsePipfileLock(reader io.Reader) (PipfileLock, error) {
+	var lock PipfileLock
+
+	if err := json.NewDecoder(reader).Decode(&lock); err != nil {
+		return PipfileLock{}, fmt.Errorf("invalid Pipfile.lock: %s", err.Error())
+	}
+
+	if lock.Default == nil {
+		lock.Default = make(map[string]LockedPackage)
+	}
+
+	if lock.Develop == nil {
+		lock.Develop = make(map[string]LockedPackage)
+	}
+
+	return lock, nil
+}
+
+// RuntimeVersions returns the versions locked in the `default` section.
+func (l PipfileLock) RuntimeVersions() (LockedVersions, error) {
+	return lockedVersions(l.Default)
+}
+
+// DevVersions returns the versions locked in the `develop` section.
+func (l PipfileLock) DevVersions() (LockedVersions, error) {
+	return lockedVersions(l.Develop)
+}
+
+// IsUpToDate returns true if the lock has been generated
+// for a Pipfile with the given hash (see ComputePipfileHash).
+func (l PipfileLock) IsUpToDate(pipfileHash string) bool {
+	return l.Meta.Hash.Sha256 == pipfileHash
+}
+
+// lockedVersions resolves the pinned version (`==x.y.z`) of the locked packages.
+// The packages resolved from VCS or local paths, without version, are ignored.
+func lockedVersions(packages map[string]LockedPackage) (LockedVersions, error) {
+	versions := make(LockedVersions)
+
+	for name, pkg := range packages {
+		if pkg.Version == "" {
+			continue
+		}
+
+		req, err := ParseVersionRequirement(pkg.Version)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+
+		if len(req) != 1 || req[0][0] != "==" {
+			return nil, fmt.Errorf("%s: locked version must be pinned: %s", name, pkg.Version)
+		}
+
+		versions[NormalizePackageName(name)] = req[0][1]
+	}
+
+	return versions, nil
+}
+
+var packageNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizePackageName returns the normalized form of a package name,
+// according PEP 503 (e.g. `Foo_Bar.baz` is normalized as `foo-bar-baz`).
+func NormalizePackageName(name string) string {
+	return strings.ToLower(packageNameSeparators.ReplaceAllString(name, "-"))
+}
+
+// pipfileHashIgnoredSections are the Pipfile sections
+// which are either specifically handled or excluded by the Pipfile hash.
+var pipfileHashIgnoredSections = []string{
+	"source", "packages", "dev-packages", "requires",
+	"scripts", "pipenv", "pipfile", "default", "develop",
+}
+
+// ComputePipfileHash computes the hash of a Pipfile,
+// the same way Pipenv does to fill `_meta.hash.sha256` in the Pipfile.lock.
+//
+// The hash is the SHA-256 of the JSON representation
+// (with sorted keys, compact separators and ASCII only)
+// of the sources, requirements and package categories.
+func ComputePipfileHash(reader io.Reader) (string, error) {
+	var document map[string]interface{}
+
+	if _, err := toml.NewDecoder(reader).Decode(&document); err != nil {
+		return "", err
+	}
+
+	sources, ok := document["source"]
+
+	if !ok {
+		sources = []map[string]interface{}{
+			{
+				"name":       "pypi",
+				"url":        "https://pypi.org/simple",
+				"verify_ssl": true,
+			},
+		}
+	}
+
+	data := map[string]interface{}{
+		"_meta": map[string]interface{}{
+			"sources":  sources,
+			"requires": pipfileSection(document, "requires"),
+		},
+		"default": pipfileSection(document, "packages"),
+		"develop": pipfileSection(document, "dev-packages"),
+	}
+
+	for section, value := range document {
+		if ContainsString(pipfileHashIgnoredSections, section) {
+			continue
+		}
+
+		data[section] = value
+	}
+
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(data); err != nil {
+		return "", err
+	}
+
+	content := asciiJSON(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func pipfileSection(
+	document map[string]interface{},
+	section string,
+) interface{} {
+	if value, ok := document[section]; ok {
+		return value
+	}
+
+	return map[string]interface{}{}
+}
+
+// asciiJSON escapes the non ASCII characters of a JSON document
+// as `\uXXXX` sequences (as Python `json.dumps` does by default).
+func asciiJSON(content []byte) []byte {
+	var buf bytes.Buffer
+
+	for _, r := range string(content) {
+		if r < 0x80 {
+			buf.WriteRune(r)
+			continue
+		}
+
+		if r > 0xFFFF {
+			r1, r2 := utf16.EncodeRune(r)
+
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+
+			continue
+		}
+
+		fmt.Fprintf(&buf, "\\u%04x", r)
+	}
+
+	return buf.Bytes()
+}
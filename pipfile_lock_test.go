@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePipfileLock(t *testing.T) {
+	file, err := os.Open("resources/valid1.pipfile.lock")
+
+	if err != nil {
+		t.Fatalf("Error occurred while loading fixture: %v", err)
+	}
+
+	defer file.Close()
+
+	lock, err := ParsePipfileLock(file)
+
+	if err != nil {
+		t.Fatalf("Error occurred while parsing Pipfile.lock: %v", err)
+	}
+
+	if lock.Meta.PipfileSpec != 6 {
+		t.Errorf("Expected pipfile-spec 6, got %d", lock.Meta.PipfileSpec)
+	}
+
+	expectedSources := []PipfileSource{
+		{Name: "pypi", Url: "https://pypi.org/simple", VerifySsl: true},
+	}
+
+	if !reflect.DeepEqual(lock.Meta.Sources, expectedSources) {
+		t.Errorf("Expected sources %v, got %v", expectedSources, lock.Meta.Sources)
+	}
+
+	if pkg := lock.Develop["wilf-client"]; !pkg.Editable || pkg.Ref != "0123456789abcdef" {
+		t.Errorf("Unexpected locked VCS package: %+v", pkg)
+	}
+
+	runtimeVersions, err := lock.RuntimeVersions()
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expectedRuntime := LockedVersions{
+		"requests": "v2.26.0",
+		"numpy":    "v1.21.6",
+	}
+
+	if !reflect.DeepEqual(runtimeVersions, expectedRuntime) {
+		t.Errorf("Expected runtime versions %v, got %v", expectedRuntime, runtimeVersions)
+	}
+
+	devVersions, err := lock.DevVersions()
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expectedDev := LockedVersions{
+		"black":  "v23.7.0",
+		"pytest": "v6.2.5",
+	}
+
+	if !reflect.DeepEqual(devVersions, expectedDev) {
+		t.Errorf("Expected dev versions %v, got %v", expectedDev, devVersions)
+	}
+}
+
+func TestParseInvalidPipfileLock(t *testing.T) {
+	_, err := ParsePipfileLock(strings.NewReader(`{"default": []}`))
+
+	if err == nil {
+		t.Errorf("Expected error for invalid Pipfile.lock")
+	}
+
+	lock, err := ParsePipfileLock(strings.NewReader(
+		`{"default": {"foo": {"version": ">=1.0"}}}`))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := lock.RuntimeVersions(); err == nil {
+		t.Errorf("Expected error for unpinned locked version")
+	}
+}
+
+func TestComputePipfileHash(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{
+			path:     "resources/valid1.pipfile",
+			expected: "55e83aa1d0a8f77ea98e301130766d2e22619fe90fa3d12d9f240c39c9029990",
+		},
+		{
+			path:     "resources/valid3.pipfile",
+			expected: "0f7b112f8d1a506d0b3313bfe4638f37dc494b5e860a3c1d11f4289d10159cf5",
+		},
+		{
+			path:     "resources/valid4.pipfile",
+			expected: "b4f386032f2130ae141b900ed7f8485d51f34f4c1474f5b5ec42fee7a75d7669",
+		},
+	}
+
+	for _, test := range tests {
+		file, err := os.Open(test.path)
+
+		if err != nil {
+			t.Fatalf("Error occurred while loading fixture '%s': %v", test.path, err)
+		}
+
+		hash, err := ComputePipfileHash(file)
+
+		file.Close()
+
+		if err != nil {
+			t.Errorf("Unexpected error for '%s': %v", test.path, err)
+		}
+
+		if hash != test.expected {
+			t.Errorf("Expected hash for '%s': %s, got: %s", test.path, test.expected, hash)
+		}
+	}
+
+	lockFile, err := os.Open("resources/valid1.pipfile.lock")
+
+	if err != nil {
+		t.Fatalf("Error occurred while loading fixture: %v", err)
+	}
+
+	defer lockFile.Close()
+
+	lock, err := ParsePipfileLock(lockFile)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !lock.IsUpToDate(tests[0].expected) {
+		t.Errorf("Expected lock to be up-to-date")
+	}
+
+	if lock.IsUpToDate(tests[1].expected) {
+		t.Errorf("Expected lock to be out-of-date")
+	}
+}
+
+func TestNormalizePackageName(t *testing.T) {
+	tests := map[string]string{
+		"requests":                   "requests",
+		"Django":                     "django",
+		"flake8_formatter_junit_xml": "flake8-formatter-junit-xml",
+		"zope.interface":             "zope-interface",
+		"Foo__Bar-.baz":              "foo-bar-baz",
+	}
+
+	for input, expected := range tests {
+		if result := NormalizePackageName(input); result != expected {
+			t.Errorf("Expected normalized name for '%s': %s, got: %s", input, expected, result)
+		}
+	}
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	log "github.com/sirupsen/logrus"
+)
+
+// poetryDocument represents the `[tool.poetry]` section of a `pyproject.toml`.
+type poetryDocument struct {
+	Dependencies    map[string]interface{} `toml:"dependencies"`
+	DevDependencies map[string]interface{} `toml:"dev-dependencies"`
+	Group           map[string]struct {
+		Dependencies map[string]interface{} `toml:"dependencies"`
+	} `toml:"group"`
+	Source []struct {
+		Name     string `toml:"name"`
+		Url      string `toml:"url"`
+		Priority string `toml:"priority"`
+	} `toml:"source"`
+}
+
+// parsePoetry merges the dependencies declared for Poetry
+// (see https://python-poetry.org/docs/pyproject/) into the given model.
+//
+// The `[tool.poetry.dependencies]` are merged as runtime dependencies,
+// the legacy `[tool.poetry.dev-dependencies]` and the `dev` group as dev dependencies,
+// and each other `[tool.poetry.group.<name>.dependencies]` as a group of dependencies.
+// The `python` dependency is used as the required Python version,
+// unless it's already specified (e.g. by PEP 621 `requires-python`).
+func parsePoetry(poetry poetryDocument, pipfile *Pipfile) error {
+	for _, source := range poetry.Source {
+		// The explicit and supplemental sources are not used
+		// for the packages which are not declared with it
+		explicit := source.Priority == "explicit" || source.Priority == "supplemental"
+
+		pipfile.Sources = append(pipfile.Sources, PipfileSource{
+			Name:      source.Name,
+			Url:       source.Url,
+			VerifySsl: true,
+			Explicit:  explicit,
+		})
+	}
+
+	if python, ok := poetry.Dependencies["python"]; ok {
+		version, err := tomlString("python", python)
+
+		if err != nil {
+			return err
+		}
+
+		if len(pipfile.RequiresPythonVersion) == 0 {
+			versionReq, err := ParsePoetryRequirement(version)
+
+			if err != nil {
+				return fmt.Errorf("python: %s", err.Error())
+			}
+
+			pipfile.Requires.PythonVersion = version
+			pipfile.RequiresPythonVersion = versionReq
+		}
+
+		delete(poetry.Dependencies, "python")
+	}
+
+	if err := mergePoetryDependencies(
+		poetry.Dependencies,
+		pipfile.Packages,
+		pipfile.RuntimeDependencies,
+	); err != nil {
+		return err
+	}
+
+	if err := mergePoetryDependencies(
+		poetry.DevDependencies,
+		pipfile.DevPackages,
+		pipfile.DevDependencies,
+	); err != nil {
+		return err
+	}
+
+	groups := make([]string, 0, len(poetry.Group))
+
+	for group := range poetry.Group {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		specs := poetry.Group[group].Dependencies
+
+		if group == "dev" {
+			if err := mergePoetryDependencies(
+				specs, pipfile.DevPackages, pipfile.DevDependencies,
+			); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		dependencies, ok := pipfile.GroupDependencies[group]
+
+		if !ok {
+			dependencies = make(Dependencies)
+			pipfile.GroupDependencies[group] = dependencies
+		}
+
+		packages, ok := pipfile.GroupPackages[group]
+
+		if !ok {
+			packages = make(map[string]PipfilePackage)
+			pipfile.GroupPackages[group] = packages
+		}
+
+		if err := mergePoetryDependencies(specs, packages, dependencies); err != nil {
+			return fmt.Errorf("%s: %s", group, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// mergePoetryDependencies resolves the version requirements
+// of the given Poetry specifications, and merges them
+// with the already declared dependencies (which take precedence).
+// If packages is not nil, it's filled with the parsed specifications.
+func mergePoetryDependencies(
+	specs map[string]interface{},
+	packages map[string]PipfilePackage,
+	dependencies Dependencies,
+) error {
+	for name, spec := range specs {
+		pkg, err := parsePoetryPackage(spec)
+
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+
+		if packages != nil {
+			if _, ok := packages[name]; !ok {
+				packages[name] = pkg
+			}
+		}
+
+		if _, ok := dependencies[name]; ok {
+			continue
+		}
+
+		if pkg.Version == "" {
+			if pkg.IsLocal() {
+				log.Debugf("Ignoring local package '%s'", name)
+
+				continue
+			}
+
+			pkg.Version = "*"
+		}
+
+		versionReq, err := ParsePoetryRequirement(pkg.Version)
+
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+
+		dependencies[name] = versionReq
+	}
+
+	return nil
+}
+
+// parsePoetryPackage parses the specification of a Poetry dependency,
+// either as a version string, a table or a list of tables
+// (multiple constraints, in which case the first one with a version is used).
+func parsePoetryPackage(spec interface{}) (PipfilePackage, error) {
+	if version, ok := spec.(string); ok {
+		return PipfilePackage{Version: version}, nil
+	}
+
+	if list, ok := spec.([]interface{}); ok {
+		for _, item := range list {
+			if table, ok := item.(map[string]interface{}); ok && table["version"] != nil {
+				return parsePoetryPackage(table)
+			}
+		}
+
+		if len(list) > 0 {
+			return parsePoetryPackage(list[0])
+		}
+	}
+
+	table, ok := spec.(map[string]interface{})
+
+	if !ok {
+		return PipfilePackage{}, fmt.Errorf("invalid dependency specification: %v", spec)
+	}
+
+	var pkg PipfilePackage
+	var err error
+
+	for key, value := range table {
+		switch key {
+		case "version":
+			pkg.Version, err = tomlString(key, value)
+
+		case "extras":
+			pkg.Extras, err = tomlStrings(key, value)
+
+		case "markers":
+			pkg.Markers, err = tomlString(key, value)
+
+		case "source":
+			pkg.Index, err = tomlString(key, value)
+
+		case "git":
+			pkg.Git, err = tomlString(key, value)
+
+		case "rev", "branch", "tag":
+			pkg.Ref, err = tomlString(key, value)
+
+		case "path":
+			pkg.Path, err = tomlString(key, value)
+
+		case "url":
+			pkg.File, err = tomlString(key, value)
+
+		case "develop":
+			pkg.Editable, err = tomlBool(key, value)
+
+		default:
+			log.Debugf("Ignoring dependency field '%s'", key)
+		}
+
+		if err != nil {
+			return PipfilePackage{}, err
+		}
+	}
+
+	return pkg, nil
+}
+
+// ParsePoetryRequirement parses a Poetry version requirement
+// (see https://python-poetry.org/docs/dependency-specification/#version-constraints),
+// as a list of constraints.
+//
+// Aside from the PEP 440 constraints (see ParseVersionRequirement),
+// the caret (e.g. `^1.2`) and tilde (e.g. `~1.2`) requirements are expanded
+// as equivalent range constraints (see ParsePoetryRange).
+// The constraints can be separated either by commas or spaces (e.g. `>=3.8 <4.0`),
+// and a wildcard (e.g. `1.2.*`) is the PEP 440 version matching (i.e. `==1.2.*`).
+//
+// As a requirement is a conjunction of constraints, alternatives separated by `||`
+// (e.g. `^3.2 || ^4.2`) are approximated by the smallest range containing
+// all of them (e.g. `>=3.2, <5.0`), so the updates beyond the last alternative are found.
+func ParsePoetryRequirement(input string) (VersionRequirement, error) {
+	alternatives := strings.Split(input, "||")
+
+	if len(alternatives) == 1 {
+		return parsePoetryConstraints(input)
+	}
+
+	union := VersionRange{}
+
+	for _, alternative := range alternatives {
+		requirement, err := parsePoetryConstraints(alternative)
+
+		if err != nil {
+			return VersionRequirement{}, err
+		}
+
+		r, err := NewVersionRange(requirement)
+
+		if err != nil {
+			return VersionRequirement{}, err
+		}
+
+		union = union.Union(r)
+	}
+
+	if union.IsEmpty() {
+		return VersionRequirement{}, fmt.Errorf("no version matching: %s", input)
+	}
+
+	var requirement VersionRequirement
+
+	if lower := union[0].Lower; !lower.Unbounded {
+		operator := ">="
+
+		if !lower.Inclusive {
+			operator = ">"
+		}
+
+		requirement = append(requirement, VersionConstraint{operator, "v" + lower.Version.String()})
+	}
+
+	if upper := union[len(union)-1].Upper; !upper.Unbounded {
+		operator := "<="
+
+		if !upper.Inclusive {
+			operator = "<"
+		}
+
+		requirement = append(requirement, VersionConstraint{operator, "v" + upper.Version.String()})
+	}
+
+	if len(requirement) == 0 {
+		return VersionRequirement{{"*", "*"}}, nil
+	}
+
+	return requirement, nil
+}
+
+// parsePoetryConstraints parses the constraints of a Poetry requirement
+// without alternative, separated by commas or spaces.
+func parsePoetryConstraints(input string) (VersionRequirement, error) {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+
+	if len(fields) == 0 {
+		return VersionRequirement{}, fmt.Errorf("missing version: %s", input)
+	}
+
+	var requirement VersionRequirement
+
+	for i := 0; i < len(fields); i++ {
+		spec := fields[i]
+
+		// Operator separated from its version (e.g. `>= 1.2`)
+		if strings.TrimLeft(spec, "=<>!~^") == "" && i+1 < len(fields) {
+			i++
+			spec += fields[i]
+		}
+
+		if strings.HasSuffix(spec, ".*") && strings.TrimLeft(spec, "=<>!~^") == spec {
+			// Wildcard (e.g. `1.2.*`), as the PEP 440 version matching
+			spec = "==" + spec
+		}
+
+		if strings.HasPrefix(spec, "^") ||
+			(strings.HasPrefix(spec, "~") && !strings.HasPrefix(spec, "~=")) {
+			constraints, err := ParsePoetryRange(spec)
+
+			if err != nil {
+				return VersionRequirement{}, err
+			}
+
+			requirement = append(requirement, constraints...)
+
+			continue
+		}
+
+		constraints, err := ParseVersionRequirement(spec)
+
+		if err != nil {
+			return VersionRequirement{}, err
+		}
+
+		requirement = append(requirement, constraints...)
+	}
+
+	return requirement, nil
+}
+
+// ParsePoetryRange parses a Poetry caret (e.g. `^1.2.3`) or tilde (e.g. `~1.2`)
+// requirement, as the equivalent range constraints.
+// See https://python-poetry.org/docs/dependency-specification/#version-constraints
+//
+// - `^1.2.3` is `>=1.2.3, <2.0.0`, `^0.2.3` is `>=0.2.3, <0.3.0`,
+// and `^0.0.3` is `>=0.0.3, <0.0.4`;
+// - `~1.2.3` is `>=1.2.3, <1.3.0`, and `~1` is `>=1, <2`.
+func ParsePoetryRange(spec string) (VersionRequirement, error) {
+	operator := spec[0:1]
+	remaining := strings.TrimSpace(spec[1:])
+
+	if remaining == "" {
+		return VersionRequirement{}, fmt.Errorf("missing version: %s", spec)
+	}
+
+	lower := fmt.Sprintf("v%s", remaining)
+
+	if !IsValidVersion(lower) {
+		return VersionRequirement{}, fmt.Errorf("invalid version: %s", remaining)
+	}
+
+	var segments []int
+
+	for _, s := range strings.Split(remaining, ".") {
+		n, err := strconv.Atoi(s)
+
+		if err != nil {
+			// Pre-release or build suffix
+			break
+		}
+
+		segments = append(segments, n)
+	}
+
+	if len(segments) == 0 {
+		return VersionRequirement{}, fmt.Errorf("invalid version: %s", remaining)
+	}
+
+	// Index of the segment to be bumped for the upper bound
+	bump := 0
+
+	if operator == "^" {
+		bump = len(segments) - 1
+
+		for i, n := range segments {
+			if n != 0 {
+				bump = i
+				break
+			}
+		}
+	} else if len(segments) > 1 {
+		bump = 1
+	}
+
+	upper := make([]string, len(segments))
+
+	for i := range segments {
+		if i < bump {
+			upper[i] = strconv.Itoa(segments[i])
+		} else if i == bump {
+			upper[i] = strconv.Itoa(segments[i] + 1)
+		} else {
+			upper[i] = "0"
+		}
+	}
+
+	return VersionRequirement{
+		VersionConstraint{">=", lower},
+		VersionConstraint{"<", fmt.Sprintf("v%s", strings.Join(upper, "."))},
+	}, nil
+}
+
+// ParsePoetryLock parses a `poetry.lock` file,
+// and returns the resolved versions of the locked packages
+// (regardless of their group, which is not part of recent lock formats).
+// The packages resolved from VCS, URL or local paths are ignored.
+func ParsePoetryLock(reader io.Reader) (LockedVersions, error) {
+	var lock struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+			Source  struct {
+				Type string `toml:"type"`
+			} `toml:"source"`
+		} `toml:"package"`
+	}
+
+	if _, err := toml.NewDecoder(reader).Decode(&lock); err != nil {
+		return nil, fmt.Errorf("invalid poetry.lock: %s", err.Error())
+	}
+
+	versions := make(LockedVersions)
+
+	for _, pkg := range lock.Package {
+		switch pkg.Source.Type {
+		case "", "legacy":
+			versionReq, err := ParseVersionRequirement("==" + pkg.Version)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", pkg.Name, err.Error())
+			}
+
+			versions[NormalizePackageName(pkg.Name)] = versionReq[0][1]
+
+		default:
+			log.Debugf("Ignoring locked package '%s' from %s", pkg.Name, pkg.Source.Type)
+		}
+	}
+
+	return versions, nil
+}
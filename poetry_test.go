@@ -0,0 +1,282 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePoetryPyproject(t *testing.T) {
+	file, err := os.Open("resources/poetry/pyproject.toml")
+
+	if err != nil {
+		t.Fatalf("Error occurred while loading fixture: %v", err)
+	}
+
+	defer file.Close()
+
+	result, err := ParsePyproject(file)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedRuntime := Dependencies{
+		"requests": VersionRequirement{
+			{">=", "v2.26"},
+			{"<", "v3.0"},
+		},
+		"pendulum": VersionRequirement{
+			{">=", "v2.1.2"},
+			{"<", "v2.2.0"},
+		},
+		"numpy": VersionRequirement{
+			{"<", "v1.25"},
+		},
+		"internal-lib": VersionRequirement{{"*", "*"}},
+	}
+
+	if !reflect.DeepEqual(result.RuntimeDependencies, expectedRuntime) {
+		t.Errorf("Expected runtime dependencies: %v, got: %v", expectedRuntime, result.RuntimeDependencies)
+	}
+
+	expectedPackages := map[string]PipfilePackage{
+		"requests": {
+			Version: "^2.26",
+			Extras:  []string{"socks"},
+		},
+		"pendulum":     {Version: "~2.1.2"},
+		"numpy":        {Version: "<1.25"},
+		"internal-lib": {Version: "*", Index: "private"},
+		"wilf-client": {
+			Git: "https://github.com/cchantep/wilf-client.git",
+			Ref: "main",
+		},
+	}
+
+	if !reflect.DeepEqual(result.Packages, expectedPackages) {
+		t.Errorf("Expected packages: %v, got: %v", expectedPackages, result.Packages)
+	}
+
+	expectedDev := Dependencies{
+		"pytest": VersionRequirement{
+			{">=", "v7.4.0"},
+			{"<", "v8.0.0"},
+		},
+	}
+
+	if !reflect.DeepEqual(result.DevDependencies, expectedDev) {
+		t.Errorf("Expected dev dependencies: %v, got: %v", expectedDev, result.DevDependencies)
+	}
+
+	expectedGroups := map[string]Dependencies{
+		"docs": {
+			"mkdocs": VersionRequirement{
+				{">=", "v1.5"},
+				{"<", "v2"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(result.GroupDependencies, expectedGroups) {
+		t.Errorf("Expected group dependencies: %v, got: %v", expectedGroups, result.GroupDependencies)
+	}
+
+	expectedPython := VersionRequirement{
+		{">=", "v3.9"},
+		{"<", "v4.0"},
+	}
+
+	if !reflect.DeepEqual(result.RequiresPythonVersion, expectedPython) {
+		t.Errorf("Expected Python requirement: %v, got: %v", expectedPython, result.RequiresPythonVersion)
+	}
+
+	expectedSources := []PipfileSource{
+		{
+			Name:      "private",
+			Url:       "https://pypi.example.com/simple",
+			VerifySsl: true,
+			Explicit:  true,
+		},
+	}
+
+	if !reflect.DeepEqual(result.Sources, expectedSources) {
+		t.Errorf("Expected sources: %v, got: %v", expectedSources, result.Sources)
+	}
+}
+
+func TestParseInvalidPoetryPyproject(t *testing.T) {
+	tests := []string{
+		"[tool.poetry.dependencies]\nrequests = 2\n",
+		"[tool.poetry.dependencies]\nrequests = \"^foo\"\n",
+		"[tool.poetry.dependencies]\npython = \"~\"\n",
+		"[tool.poetry.group.docs.dependencies]\nmkdocs = { version = 1 }\n",
+	}
+
+	for _, input := range tests {
+		if _, err := ParsePyproject(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for pyproject: %s", input)
+		}
+	}
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	file, err := os.Open("resources/poetry/poetry.lock")
+
+	if err != nil {
+		t.Fatalf("Error occurred while loading fixture: %v", err)
+	}
+
+	defer file.Close()
+
+	result, err := ParsePoetryLock(file)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := LockedVersions{
+		"requests":     "v2.31.0",
+		"pytest":       "v7.4.3",
+		"internal-lib": "v1.0.2",
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected locked versions: %v, got: %v", expected, result)
+	}
+
+	_, err = ParsePoetryLock(strings.NewReader("[[package]]\nname = \"foo\"\nversion = \"bar\"\n"))
+
+	if err == nil {
+		t.Errorf("Expected error for invalid locked version")
+	}
+}
+
+func TestParsePoetryRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected VersionRequirement
+		err      bool
+	}{
+		{"^1.2.3", VersionRequirement{{">=", "v1.2.3"}, {"<", "v2.0.0"}}, false},
+		{"^1.2", VersionRequirement{{">=", "v1.2"}, {"<", "v2.0"}}, false},
+		{"^1", VersionRequirement{{">=", "v1"}, {"<", "v2"}}, false},
+		{"^0.2.3", VersionRequirement{{">=", "v0.2.3"}, {"<", "v0.3.0"}}, false},
+		{"^0.0.3", VersionRequirement{{">=", "v0.0.3"}, {"<", "v0.0.4"}}, false},
+		{"^0.0", VersionRequirement{{">=", "v0.0"}, {"<", "v0.1"}}, false},
+		{"^0", VersionRequirement{{">=", "v0"}, {"<", "v1"}}, false},
+		{"~1.2.3", VersionRequirement{{">=", "v1.2.3"}, {"<", "v1.3.0"}}, false},
+		{"~1.2", VersionRequirement{{">=", "v1.2"}, {"<", "v1.3"}}, false},
+		{"~1", VersionRequirement{{">=", "v1"}, {"<", "v2"}}, false},
+		{"^", nil, true},
+		{"~a.b", nil, true},
+	}
+
+	for _, test := range tests {
+		result, err := ParsePoetryRange(test.input)
+
+		if (err != nil) != test.err {
+			t.Errorf("For '%s', expected error: %v, got: %v", test.input, test.err, err)
+		}
+
+		if !test.err && !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("For '%s', expected: %v, got: %v", test.input, test.expected, result)
+		}
+	}
+
+	// Through ParsePoetryRequirement, with PEP 440 compatible release unchanged
+	result, err := ParsePoetryRequirement("^1.2, !=1.4.0")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := VersionRequirement{{">=", "v1.2"}, {"<", "v2.0"}, {"!=", "v1.4.0"}}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, result)
+	}
+
+	result, err = ParsePoetryRequirement("~=1.2")
+
+	if err != nil || !reflect.DeepEqual(result, VersionRequirement{{"~=", "v1.2"}}) {
+		t.Errorf("Unexpected result for compatible release: %v (%v)", result, err)
+	}
+}
+
+func TestParsePoetryRequirement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected VersionRequirement
+		err      bool
+	}{
+		{">=3.8 <4.0", VersionRequirement{{">=", "v3.8"}, {"<", "v4.0"}}, false},
+		{">=3.8,<4.0", VersionRequirement{{">=", "v3.8"}, {"<", "v4.0"}}, false},
+		{">= 3.8, < 4.0", VersionRequirement{{">=", "v3.8"}, {"<", "v4.0"}}, false},
+		{"^1.2 !=1.4.0", VersionRequirement{{">=", "v1.2"}, {"<", "v2.0"}, {"!=", "v1.4.0"}}, false},
+		{"1.2.3", VersionRequirement{{"==", "v1.2.3"}}, false},
+		{"*", VersionRequirement{{"*", "*"}}, false},
+		{"1.*", VersionRequirement{{"~", "v1.*"}}, false},
+		{"1.2.*", VersionRequirement{{"~", "v1.2.*"}}, false},
+		{"1.2.* !=1.2.5", VersionRequirement{{"~", "v1.2.*"}, {"!=", "v1.2.5"}}, false},
+
+		// Alternatives approximated by the smallest range containing them
+		{"^3.2 || ^4.2", VersionRequirement{{">=", "v3.2"}, {"<", "v5.0"}}, false},
+		{"~1.2 || >=2.0", VersionRequirement{{">=", "v1.2"}}, false},
+		{"<1.0 || ==1.5.0", VersionRequirement{{"<=", "v1.5.0"}}, false},
+		{"<1.0 || >=2.0", VersionRequirement{{"*", "*"}}, false},
+		{"1.2.* || ^2.0", VersionRequirement{{">=", "v1.2.dev0"}, {"<", "v3.0"}}, false},
+
+		{"", nil, true},
+		{"^3.2 ||", nil, true},
+		{">=3.8 <foo", nil, true},
+		{"1.2a1.*", nil, true},
+	}
+
+	for _, test := range tests {
+		result, err := ParsePoetryRequirement(test.input)
+
+		if (err != nil) != test.err {
+			t.Errorf("For '%s', expected error: %v, got: %v", test.input, test.err, err)
+		}
+
+		if !test.err && !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("For '%s', expected: %v, got: %v", test.input, test.expected, result)
+		}
+	}
+
+	// The Poetry operators are not PEP 440 ones
+	for _, input := range []string{"^1.2", "~1.2", ">=3.8 <4.0"} {
+		if _, err := ParseVersionRequirement(input); err == nil {
+			t.Errorf("Expected error for non-PEP 440 requirement '%s'", input)
+		}
+	}
+}
+
+func TestParsePoetryPyprojectMultipleConstraints(t *testing.T) {
+	input := "[tool.poetry.dependencies]\npython = \">=3.8 <4.0\"\ndjango = \"^3.2 || ^4.2\"\n"
+
+	result, err := ParsePyproject(strings.NewReader(input))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedPython := VersionRequirement{{">=", "v3.8"}, {"<", "v4.0"}}
+
+	if !reflect.DeepEqual(result.RequiresPythonVersion, expectedPython) {
+		t.Errorf("Expected Python requirement: %v, got: %v", expectedPython, result.RequiresPythonVersion)
+	}
+
+	expected := Dependencies{"django": VersionRequirement{{">=", "v3.2"}, {"<", "v5.0"}}}
+
+	if !reflect.DeepEqual(result.RuntimeDependencies, expected) {
+		t.Errorf("Expected runtime dependencies: %v, got: %v", expected, result.RuntimeDependencies)
+	}
+
+	// The Poetry operators are not accepted in a Pipfile
+	if _, err := ParsePipfile(strings.NewReader("[packages]\nrequests = \"^2.26\"\n")); err == nil {
+		t.Errorf("Expected error for Poetry requirement in a Pipfile")
+	}
+}
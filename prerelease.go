@@ -0,0 +1,85 @@
+package main
+
+import "fmt"
+
+// PrereleasePolicy indicates whether the pre-releases
+// (e.g. `2.0rc1` or `2.0.dev1`) can be candidate versions.
+type PrereleasePolicy uint
+
+const (
+	// Pre-releases are never candidates
+	PrereleaseNever PrereleasePolicy = 1
+
+	// Pre-releases are candidates only if the current requirement
+	// is already on a pre-release (e.g. `==2.0b1`)
+	PrereleaseIfCurrent PrereleasePolicy = 2
+
+	// Pre-releases are always candidates
+	PrereleaseAlways PrereleasePolicy = 3
+)
+
+func (p PrereleasePolicy) String() string {
+	switch p {
+	case PrereleaseNever:
+		return "never"
+	case PrereleaseIfCurrent:
+		return "if-current"
+	case PrereleaseAlways:
+		return "always"
+	}
+
+	return "<undefined>"
+}
+
+// ParsePrereleasePolicy parses a string representation of a PrereleasePolicy
+// (`never`, `if-current` or `always`).
+// If the string representation is not valid, an error is returned.
+func ParsePrereleasePolicy(s string) (PrereleasePolicy, error) {
+	switch s {
+	case "never":
+		return PrereleaseNever, nil
+	case "if-current":
+		return PrereleaseIfCurrent, nil
+	case "always":
+		return PrereleaseAlways, nil
+	default:
+		return 0, fmt.Errorf("invalid PrereleasePolicy: %s", s)
+	}
+}
+
+// DefaultPrereleasePolicy returns the policy corresponding
+// to the `allow_prereleases` setting of a Pipfile:
+// PrereleaseAlways if allowed, otherwise PrereleaseIfCurrent.
+func DefaultPrereleasePolicy(allowPrereleases bool) PrereleasePolicy {
+	if allowPrereleases {
+		return PrereleaseAlways
+	}
+
+	return PrereleaseIfCurrent
+}
+
+// Allows checks whether pre-releases can be candidates
+// to update the given requirement.
+// An undefined policy is considered as PrereleaseIfCurrent.
+func (p PrereleasePolicy) Allows(requirement VersionRequirement) bool {
+	switch p {
+	case PrereleaseAlways:
+		return true
+	case PrereleaseNever:
+		return false
+	}
+
+	for _, constraint := range requirement {
+		if constraint[0] == "*" {
+			continue
+		}
+
+		v, err := ParseVersion(constraint[1])
+
+		if err == nil && v.IsPreRelease() {
+			return true
+		}
+	}
+
+	return false
+}
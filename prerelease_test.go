@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParsePrereleasePolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    PrereleasePolicy
+		wantErr bool
+	}{
+		{"never", PrereleaseNever, false},
+		{"if-current", PrereleaseIfCurrent, false},
+		{"always", PrereleaseAlways, false},
+		{"sometimes", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePrereleasePolicy(test.input)
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParsePrereleasePolicy(%q) error = %v, wantErr %v", test.input, err, test.wantErr)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParsePrereleasePolicy(%q) = %v, want %v", test.input, got, test.want)
+		}
+
+		if err == nil && got.String() != test.input {
+			t.Errorf("Expected %s, but got %s", test.input, got.String())
+		}
+	}
+
+	if DefaultPrereleasePolicy(true) != PrereleaseAlways {
+		t.Errorf("Expected pre-releases to be always allowed")
+	}
+
+	if DefaultPrereleasePolicy(false) != PrereleaseIfCurrent {
+		t.Errorf("Expected pre-releases to be allowed if current")
+	}
+}
+
+func TestPrereleasePolicyAllows(t *testing.T) {
+	stable := VersionRequirement{{">=", "v1.0"}, {"<", "v2.0"}}
+	pre := VersionRequirement{{"==", "v2.0rc1"}}
+	wildcard := VersionRequirement{{"*", "*"}}
+
+	tests := []struct {
+		policy      PrereleasePolicy
+		requirement VersionRequirement
+		expected    bool
+	}{
+		{PrereleaseNever, stable, false},
+		{PrereleaseNever, pre, false},
+		{PrereleaseIfCurrent, stable, false},
+		{PrereleaseIfCurrent, pre, true},
+		{PrereleaseIfCurrent, wildcard, false},
+		{0, pre, true},
+		{PrereleaseAlways, stable, true},
+		{PrereleaseAlways, wildcard, true},
+	}
+
+	for _, test := range tests {
+		if a := test.policy.Allows(test.requirement); a != test.expected {
+			t.Errorf("For policy %s and requirement %v, expected %v, but got %v",
+				test.policy, test.requirement, test.expected, a)
+		}
+	}
+}
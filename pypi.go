@@ -6,21 +6,112 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/BurntSushi/toml"
 )
 
+// Base URL of the PyPI JSON API
+const DefaultPypiJsonUrl = "https://pypi.org/pypi"
+
+// Base URL of the PyPI Simple Repository API
+const DefaultPypiSimpleUrl = "https://pypi.org/simple"
+
+// IndexApi is the kind of API provided by a package index.
+type IndexApi string
+
+const (
+	// PyPI JSON API (`<url>/<name>/json`)
+	JsonIndexApi IndexApi = "json"
+
+	// Simple Repository API (see PEP 503 and PEP 691)
+	SimpleIndexApi IndexApi = "simple"
+)
+
+// IndexConfig represents the configuration of the package index
+// (e.g. a private index or a devpi/Nexus/Artifactory proxy),
+// used instead of PyPI.
+type IndexConfig struct {
+	Url string   `toml:"url"`
+	Api IndexApi `toml:"api"`
+}
+
 type ProjectInfo struct {
 	Name           string `json:"name"`
 	Version        string `json:"version"`
 	RequiresPython string `json:"requires_python"`
 	Summary        string `json:"summary"`
 	HomeURL        string `json:"home_page"`
+
+	// Released versions of the project (from the `releases` of the JSON API)
+	Releases []ReleaseInfo `json:"-"`
 }
 
-func GetProjectInfo(packageName string) (*ProjectInfo, error) {
-	url := fmt.Sprintf("https://pypi.org/pypi/%s/json", packageName)
+// releaseFile represents a file of a release in the PyPI JSON API.
+type releaseFile struct {
+	RequiresPython *string `json:"requires_python"`
+	Yanked         bool    `json:"yanked"`
+	YankedReason   *string `json:"yanked_reason"`
+}
+
+// LoadIndexConfig loads the package index configuration
+// from the `[index]` section of a TOML file.
+// If the API is not specified, the JSON API is assumed;
+// if the URL is not specified, the PyPI one for the API is used.
+func LoadIndexConfig(path string) (*IndexConfig, error) {
+	var config struct {
+		Index IndexConfig `toml:"index"`
+	}
+
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		return nil, err
+	}
+
+	index := config.Index
+
+	switch index.Api {
+	case "", JsonIndexApi:
+		index.Api = JsonIndexApi
+
+		if index.Url == "" {
+			index.Url = DefaultPypiJsonUrl
+		}
+
+	case SimpleIndexApi:
+		if index.Url == "" {
+			index.Url = DefaultPypiSimpleUrl
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid index API: %s", index.Api)
+	}
+
+	return &index, nil
+}
+
+// GetProjectInfo retrieves information about a project from the PyPI JSON API
+// at the given base URL (DefaultPypiJsonUrl if empty),
+// using the given HTTP client (or the default one if nil).
+// If the package is not found (e.g. a 404 response), it returns nil and no error.
+func GetProjectInfo(
+	client *http.Client,
+	indexUrl string,
+	packageName string,
+) (*ProjectInfo, error) {
+	if indexUrl == "" {
+		indexUrl = DefaultPypiJsonUrl
+	}
+
+	projectUrl := fmt.Sprintf("%s/%s/json",
+		strings.TrimSuffix(indexUrl, "/"), url.PathEscape(packageName))
+
+	if client == nil {
+		client = http.DefaultClient
+	}
 
 	// Send GET request to the API
-	response, err := http.Get(url)
+	response, err := client.Get(projectUrl)
 
 	if err != nil {
 		return nil, err
@@ -28,6 +119,19 @@ func GetProjectInfo(packageName string) (*ProjectInfo, error) {
 
 	defer response.Body.Close()
 
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if err := checkTransientStatus(projectUrl, response); err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fails to get project information from %s: %s",
+			redactedUrl(projectUrl), response.Status)
+	}
+
 	// Read the response body
 	body, err := io.ReadAll(response.Body)
 
@@ -37,7 +141,8 @@ func GetProjectInfo(packageName string) (*ProjectInfo, error) {
 
 	// Unmarshal the JSON response into a ProjectInfo struct
 	var projectInfo struct {
-		Info ProjectInfo `json:"info"`
+		Info     ProjectInfo              `json:"info"`
+		Releases map[string][]releaseFile `json:"releases"`
 	}
 
 	err = json.Unmarshal(body, &projectInfo)
@@ -49,6 +154,7 @@ func GetProjectInfo(packageName string) (*ProjectInfo, error) {
 	// Check if the 'info' field is nil
 	if projectInfo.Info.Name != "" {
 		projectInfo.Info.Version = fmt.Sprintf("v%s", projectInfo.Info.Version)
+		projectInfo.Info.Releases = pypiReleases(projectInfo.Releases)
 
 		return &projectInfo.Info, nil
 	}
@@ -65,11 +171,49 @@ func GetProjectInfo(packageName string) (*ProjectInfo, error) {
 		return nil, nil
 	}
 
-	errMsg := err.Error()
+	errMsg := jsonResp.Message
 
-	if err2 == nil {
-		errMsg = jsonResp.Message
+	if err2 != nil {
+		errMsg = err2.Error()
 	}
 
 	return nil, errors.New(fmt.Sprintf("Project information not found in the JSON response: %s", errMsg))
 }
+
+// pypiReleases returns the releases from the PyPI JSON API,
+// ignoring the ones without any file (so which cannot be installed).
+// A release is considered as yanked if all its files are yanked.
+func pypiReleases(releases map[string][]releaseFile) []ReleaseInfo {
+	infos := make([]ReleaseInfo, 0, len(releases))
+
+	for version, files := range releases {
+		if len(files) == 0 {
+			continue
+		}
+
+		info := ReleaseInfo{
+			Version: fmt.Sprintf("v%s", version),
+			Yanked:  true,
+		}
+
+		for _, file := range files {
+			if info.RequiresPython == "" && file.RequiresPython != nil {
+				info.RequiresPython = *file.RequiresPython
+			}
+
+			if !file.Yanked {
+				info.Yanked = false
+			} else if info.YankedReason == "" && file.YankedReason != nil {
+				info.YankedReason = *file.YankedReason
+			}
+		}
+
+		if !info.Yanked {
+			info.YankedReason = ""
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
@@ -1,50 +1,158 @@
 package main
 
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
 // PypiChecker is a struct that represents a PyPI checker.
 type PypiChecker struct {
 	PythonRequirement VersionRequirement
+
+	// Policy about the pre-releases as candidate versions
+	Prereleases PrereleasePolicy
+
+	// Base URL of the JSON API (DefaultPypiJsonUrl if empty)
+	IndexUrl string
+
+	// HTTP client to query the registry (the default one if nil)
+	Client *http.Client
 }
 
 // RequiredUpdate checks if a package requires an update.
-// It returns the current version of the package, the update level,
+// It returns the latest version of the package, the latest one
+// which is compatible with the required Python version, the update level,
 // the home URL of the package, and an error (if any).
+// If the package is unknown to the registry, the result is NotFound.
 func (c PypiChecker) RequiredUpdate(
 	pkg string,
 	requirement VersionRequirement,
-) (string, UpdateLevel, string, error) {
-	info, err := GetProjectInfo(pkg)
+) (CheckResult, error) {
+	info, err := GetProjectInfo(c.Client, c.IndexUrl, pkg)
 
 	if err != nil {
-		return "", 0, "", err
+		return CheckResult{}, err
 	}
 
 	if info == nil {
-		return "", 0, "", nil
+		return CheckResult{NotFound: true}, nil
 	}
 
-	if info.RequiresPython != "" && len(c.PythonRequirement) > 0 {
-		pkgPythonReq, err := ParseVersionRequirement(info.RequiresPython)
+	return c.checkProject(*info, requirement)
+}
+
+// checkProject checks the project information against the requirement.
+func (c PypiChecker) checkProject(
+	info ProjectInfo,
+	requirement VersionRequirement,
+) (CheckResult, error) {
+	latest, installable, err := c.candidates(info, requirement)
 
-		if err != nil {
-			return "", 0, "", err
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	result := CheckResult{
+		LatestVersion:      latest,
+		InstallableVersion: installable,
+		PackageUrl:         info.HomeURL,
+		Registry:           "pypi",
+	}
+
+	if pinned := PinnedVersion(requirement); pinned != "" {
+		if release := FindRelease(info.Releases, pinned); release != nil && release.Yanked {
+			result.Yanked = true
+			result.YankedReason = release.YankedReason
 		}
+	}
+
+	if installable == "" || !ShouldUpdate(requirement, installable) {
+		return result, nil
+	}
+
+	lvl, err := CreateUpdateLevel(requirement, installable)
 
-		if !AreCompatibles(c.PythonRequirement, pkgPythonReq) {
-			// Python version are not compatible,
-			// so package itself should not be updated
-			return info.Version, 0, info.HomeURL, nil
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	result.UpdateLevel = lvl
+
+	return result, nil
+}
+
+// candidates returns the latest version of the project,
+// and the latest one which is compatible with the required Python version
+// (or an empty string if no release is compatible).
+// The yanked releases are ignored (see PEP 592),
+// and the pre-releases are only considered according the policy.
+// A release whose `requires_python` cannot be parsed is considered compatible,
+// with a warning, so the latest version is not hidden by invalid metadata.
+func (c PypiChecker) candidates(
+	info ProjectInfo,
+	requirement VersionRequirement,
+) (string, string, error) {
+	if len(info.Releases) == 0 {
+		// Only the latest version is known
+		compatible, err := c.isCompatible(info.RequiresPython)
+
+		if err != nil || !compatible {
+			return info.Version, "", err
+		}
+
+		return info.Version, info.Version, nil
+	}
+
+	allowPrereleases := c.Prereleases.Allows(requirement)
+
+	isCandidate := func(release ReleaseInfo, v Version) bool {
+		return !release.Yanked && (allowPrereleases || !v.IsPreRelease())
+	}
+
+	latest := LatestRelease(info.Releases, isCandidate)
+
+	if latest == nil {
+		return "", "", nil
+	}
+
+	installable := LatestRelease(info.Releases, func(release ReleaseInfo, v Version) bool {
+		if !isCandidate(release, v) {
+			return false
 		}
+
+		compatible, err := c.isCompatible(release.RequiresPython)
+
+		if err != nil {
+			// As pip does, an invalid requirement doesn't exclude the release
+			log.Warnf("Ignoring invalid requires_python of %s %s: %s",
+				info.Name, release.Version, err.Error())
+
+			return true
+		}
+
+		return compatible
+	})
+
+	if installable == nil {
+		return latest.Version, "", nil
 	}
 
-	if !ShouldUpdate(requirement, info.Version) {
-		return info.Version, 0, info.HomeURL, nil
+	return latest.Version, installable.Version, nil
+}
+
+// isCompatible checks whether the Python requirement of a package
+// is compatible with the required Python version.
+func (c PypiChecker) isCompatible(requiresPython string) (bool, error) {
+	if requiresPython == "" || len(c.PythonRequirement) == 0 {
+		return true, nil
 	}
 
-	lvl, err := CreateUpdateLevel(requirement, info.Version)
+	pkgPythonReq, err := ParseVersionRequirement(requiresPython)
 
 	if err != nil {
-		return "", 0, "", err
+		return false, err
 	}
 
-	return info.Version, lvl, info.HomeURL, nil
+	return AreCompatibles(c.PythonRequirement, pkgPythonReq), nil
 }
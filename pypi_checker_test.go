@@ -49,7 +49,8 @@ func TestPypiRequiredUpdate(t *testing.T) {
 			expectedUrl:   "",
 			expectedError: nil,
 		},
-		// Test case with python version incompatible with update
+		// Test case with python version incompatible with latest version,
+		// but compatible with an older major update
 		{
 			pkg: "pytest",
 			requirement: VersionRequirement{
@@ -59,7 +60,7 @@ func TestPypiRequiredUpdate(t *testing.T) {
 				VersionConstraint{"==", "v3.7"},
 			},
 			expectedVer:   "v9.0.2",
-			expectedLvl:   0,
+			expectedLvl:   Major,
 			expectedUrl:   "",
 			expectedError: nil,
 		},
@@ -79,7 +80,8 @@ func TestPypiRequiredUpdate(t *testing.T) {
 	for _, test := range tests {
 		pypiChecker.PythonRequirement = test.pythonVersion
 
-		ver, lvl, url, err := pypiChecker.RequiredUpdate(test.pkg, test.requirement)
+		result, err := pypiChecker.RequiredUpdate(test.pkg, test.requirement)
+		ver, lvl, url := result.LatestVersion, result.UpdateLevel, result.PackageUrl
 
 		if (err == nil && test.expectedError != nil) ||
 			(err != nil && test.expectedError == nil) ||
@@ -101,3 +103,203 @@ func TestPypiRequiredUpdate(t *testing.T) {
 		}
 	}
 }
+
+func TestPypiCheckProject(t *testing.T) {
+	info := ProjectInfo{
+		Name:           "lorem",
+		Version:        "v3.1.0",
+		RequiresPython: ">=3.10",
+		HomeURL:        "https://lorem",
+		Releases: []ReleaseInfo{
+			{Version: "v2.0.0", RequiresPython: ">=3.6"},
+			{Version: "v2.1.0", RequiresPython: ">=3.7, <3.10"},
+			{Version: "v2.2.0", RequiresPython: ">=3.8"},
+			{Version: "v2.3.0rc1", RequiresPython: ">=3.8"},
+			{Version: "v3.0.0", RequiresPython: ">=3.9"},
+			{Version: "v3.1.0", RequiresPython: ">=3.10"},
+			{Version: "v4.0.0a1", RequiresPython: ">=3.11"},
+			{Version: "not-a-version"},
+		},
+	}
+
+	tests := []struct {
+		pythonVersion       string
+		requirement         VersionRequirement
+		expectedInstallable string
+		expectedLvl         UpdateLevel
+		expectedBlocked     bool
+	}{
+		{"", VersionRequirement{{"~=", "v2.0"}}, "v3.1.0", Major, false},
+		{"==3.11.*", VersionRequirement{{"~=", "v2.0"}}, "v3.1.0", Major, false},
+		{"==3.9.*", VersionRequirement{{"~=", "v2.0"}}, "v3.0.0", Major, true},
+		{"==3.8.*", VersionRequirement{{"~=", "v2.0"}}, "v2.2.0", 0, true},
+		{"==3.8.*", VersionRequirement{{"==", "v2.0.0"}}, "v2.2.0", Minor, true},
+		{"==3.6.*", VersionRequirement{{"==", "v2.0.0"}}, "v2.0.0", 0, true},
+		{"==3.5.*", VersionRequirement{{"==", "v2.0.0"}}, "", 0, true},
+		{"==3.11.*", VersionRequirement{{">=", "v3.1"}}, "v3.1.0", 0, false},
+	}
+
+	for _, test := range tests {
+		checker := PypiChecker{}
+
+		if test.pythonVersion != "" {
+			req, err := ParseVersionRequirement(test.pythonVersion)
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			checker.PythonRequirement = req
+		}
+
+		result, err := checker.checkProject(info, test.requirement)
+
+		if err != nil {
+			t.Errorf("For Python %s, unexpected error: %v", test.pythonVersion, err)
+			continue
+		}
+
+		if result.LatestVersion != info.Version {
+			t.Errorf("For Python %s, expected latest version: %s, but got: %s",
+				test.pythonVersion, info.Version, result.LatestVersion)
+		}
+
+		if result.InstallableVersion != test.expectedInstallable {
+			t.Errorf("For Python %s, expected installable version: %s, but got: %s",
+				test.pythonVersion, test.expectedInstallable, result.InstallableVersion)
+		}
+
+		if result.UpdateLevel != test.expectedLvl {
+			t.Errorf("For Python %s and requirement %v, expected update level: %s, but got: %s",
+				test.pythonVersion, test.requirement, test.expectedLvl, result.UpdateLevel)
+		}
+
+		if b := result.IsBlocked(test.requirement); b != test.expectedBlocked {
+			t.Errorf("For Python %s and requirement %v, expected blocked: %v, but got: %v",
+				test.pythonVersion, test.requirement, test.expectedBlocked, b)
+		}
+
+		if result.PackageUrl != info.HomeURL {
+			t.Errorf("Expected url: %s, but got: %s", info.HomeURL, result.PackageUrl)
+		}
+	}
+}
+
+func TestPypiCheckProjectPrereleases(t *testing.T) {
+	info := ProjectInfo{
+		Name:    "lorem",
+		Version: "v1.2.0",
+		Releases: []ReleaseInfo{
+			{Version: "v1.1.0"},
+			{Version: "v1.2.0"},
+			{Version: "v1.3.0b1", RequiresPython: ">=3.12"},
+			{Version: "v1.3.0.dev1"},
+		},
+	}
+
+	tests := []struct {
+		policy              PrereleasePolicy
+		requirement         VersionRequirement
+		expectedLatest      string
+		expectedInstallable string
+	}{
+		{PrereleaseNever, VersionRequirement{{"==", "v1.1.0"}}, "v1.2.0", "v1.2.0"},
+		{PrereleaseNever, VersionRequirement{{"==", "v1.3.0.dev1"}}, "v1.2.0", "v1.2.0"},
+		{PrereleaseIfCurrent, VersionRequirement{{"==", "v1.1.0"}}, "v1.2.0", "v1.2.0"},
+		{PrereleaseIfCurrent, VersionRequirement{{"==", "v1.3.0.dev1"}}, "v1.3.0b1", "v1.3.0.dev1"},
+		{PrereleaseAlways, VersionRequirement{{"==", "v1.1.0"}}, "v1.3.0b1", "v1.3.0.dev1"},
+	}
+
+	pythonReq := VersionRequirement{{"~", "v3.11.*"}}
+
+	for _, test := range tests {
+		checker := PypiChecker{
+			PythonRequirement: pythonReq,
+			Prereleases:       test.policy,
+		}
+
+		result, err := checker.checkProject(info, test.requirement)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			continue
+		}
+
+		if result.LatestVersion != test.expectedLatest {
+			t.Errorf("For policy %s and requirement %v, expected latest version: %s, but got: %s",
+				test.policy, test.requirement, test.expectedLatest, result.LatestVersion)
+		}
+
+		if result.InstallableVersion != test.expectedInstallable {
+			t.Errorf("For policy %s and requirement %v, expected installable version: %s, but got: %s",
+				test.policy, test.requirement, test.expectedInstallable, result.InstallableVersion)
+		}
+	}
+}
+
+func TestPypiCheckProjectYanked(t *testing.T) {
+	info := ProjectInfo{
+		Name:    "lorem",
+		Version: "v1.2.0",
+		Releases: []ReleaseInfo{
+			{Version: "v1.0.0", Yanked: true, YankedReason: "Broken"},
+			{Version: "v1.1.0"},
+			{Version: "v1.2.0", Yanked: true},
+		},
+	}
+
+	checker := PypiChecker{}
+
+	result, err := checker.checkProject(info, VersionRequirement{{"==", "v1.0"}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := CheckResult{
+		LatestVersion:      "v1.1.0",
+		InstallableVersion: "v1.1.0",
+		UpdateLevel:        Minor,
+		Registry:           "pypi",
+		Yanked:             true,
+		YankedReason:       "Broken",
+	}
+
+	if result != expected {
+		t.Errorf("Expected %+v, but got %+v", expected, result)
+	}
+
+	result, err = checker.checkProject(info, VersionRequirement{{">=", "v1.0.0"}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Yanked || result.UpdateLevel != 0 || result.LatestVersion != "v1.1.0" {
+		t.Errorf("Unexpected result for unpinned requirement: %+v", result)
+	}
+}
+
+func TestPypiCheckProjectInvalidRequiresPython(t *testing.T) {
+	info := ProjectInfo{
+		Name:    "lorem",
+		Version: "v2.0.0",
+		Releases: []ReleaseInfo{
+			{Version: "v1.0.0", RequiresPython: ">=3.6"},
+			{Version: "v2.0.0", RequiresPython: ">=3.8.*"},
+		},
+	}
+
+	checker := PypiChecker{PythonRequirement: VersionRequirement{{"~", "v3.9"}}}
+
+	result, err := checker.checkProject(info, VersionRequirement{{"==", "v1.0.0"}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The release with an invalid requirement is not hidden
+	if result.InstallableVersion != "v2.0.0" || result.UpdateLevel != Major {
+		t.Errorf("Expected installable update to v2.0.0, but got %+v", result)
+	}
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
 func TestGetProjectInfo(t *testing.T) {
-	result, err := GetProjectInfo("requests")
+	result, err := GetProjectInfo(nil, "", "requests")
 
 	if err != nil {
 		t.Errorf("Unexpected error occurred for 'requests': %v", err)
@@ -20,6 +26,12 @@ func TestGetProjectInfo(t *testing.T) {
 		HomeURL:        "https://requests.readthedocs.io",
 	}
 
+	if result == nil || FindRelease(result.Releases, "v2.26.0") == nil {
+		t.Fatalf("Expected releases for 'requests': %+v", result)
+	}
+
+	result.Releases = nil
+
 	if !reflect.DeepEqual(result, expected) {
 		t.Errorf(
 			"Expected result for 'requests': %+v, got: %+v",
@@ -27,13 +39,59 @@ func TestGetProjectInfo(t *testing.T) {
 	}
 
 	// Test case: Get project info for an invalid package name
-	result, err = GetProjectInfo("invalid-package-name")
+	result, err = GetProjectInfo(nil, "", "invalid-package-name")
 
 	if err != nil || result != nil {
 		t.Errorf("Expected result to be nil for 'invalid-package-name'")
 	}
 }
 
+func TestGetProjectInfoStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/pypi/foo%2Fbar/json":
+			io.WriteString(w, `{"info": {"name": "foo/bar", "version": "1.0.0"}, "releases": {}}`)
+		case "/pypi/forbidden/json":
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<html>Forbidden</html>")
+		case "/pypi/message/json":
+			io.WriteString(w, `{"message": 1}`)
+		default:
+			// Private index answering with HTML
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<html>Not Found</html>")
+		}
+	}))
+
+	defer server.Close()
+
+	indexUrl := server.URL + "/pypi/"
+
+	info, err := GetProjectInfo(nil, indexUrl, "foo/bar")
+
+	if err != nil || info == nil || info.Version != "v1.0.0" {
+		t.Errorf("Expected project info for escaped name, but got %v (%v)", info, err)
+	}
+
+	info, err = GetProjectInfo(nil, indexUrl, "missing")
+
+	if err != nil || info != nil {
+		t.Errorf("Expected no project info for 404, but got %v (%v)", info, err)
+	}
+
+	_, err = GetProjectInfo(nil, indexUrl, "forbidden")
+
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden") {
+		t.Errorf("Expected status error for 403, but got %v", err)
+	}
+
+	_, err = GetProjectInfo(nil, indexUrl, "message")
+
+	if err == nil || !strings.Contains(err.Error(), "not found in the JSON response") {
+		t.Errorf("Expected error for invalid JSON response, but got %v", err)
+	}
+}
+
 func TestIsValidVersion(t *testing.T) {
 	testCases := []struct {
 		version string
@@ -42,15 +100,18 @@ func TestIsValidVersion(t *testing.T) {
 		{"v1.0.0", true},
 		{"v1.0.0-alpha", true},
 		{"v1.0.0-alpha.1", true},
-		{"v1.0.0-0.3.7", true},
-		{"v1.0.0-x.7.z.92", true},
+		{"v1.0.0-0.3.7", false},
+		{"v1.0.0-x.7.z.92", false},
 		{"v1.0.0-rc.1+build.1", true},
 		{"v1.0.0+0.3.7", true},
 		{"v1.0.0-beta+exp.sha.5114f85", true},
 		{"v1.5.5.1", true},
-		{"v1.5.5.1-alpha", false},
-		{"v1.5.5.1+build.1", false},
-		{"v1.5.5.1-beta+exp.sha.5114f85", false},
+		{"v1.5.5.1-alpha", true},
+		{"v1.5.5.1+build.1", true},
+		{"v1.5.5.1-beta+exp.sha.5114f85", true},
+		{"v1!2.0.post1.dev2", true},
+		{"v1.0.0-", false},
+		{"v1.0+", false},
 	}
 
 	for _, tc := range testCases {
@@ -60,3 +121,47 @@ func TestIsValidVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestPypiReleases(t *testing.T) {
+	data := `{
+		"1.0.0": [
+			{"requires_python": null, "yanked": false, "yanked_reason": null}
+		],
+		"1.1.0": [
+			{"requires_python": ">=3.8", "yanked": true, "yanked_reason": "Broken wheel"},
+			{"requires_python": ">=3.8", "yanked": false, "yanked_reason": null}
+		],
+		"1.2.0": [
+			{"requires_python": ">=3.8", "yanked": true, "yanked_reason": null},
+			{"requires_python": ">=3.8", "yanked": true, "yanked_reason": "Security issue"}
+		],
+		"1.3.0": []
+	}`
+
+	var releases map[string][]releaseFile
+
+	if err := json.Unmarshal([]byte(data), &releases); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	infos := pypiReleases(releases)
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Version < infos[j].Version
+	})
+
+	expected := []ReleaseInfo{
+		{Version: "v1.0.0"},
+		{Version: "v1.1.0", RequiresPython: ">=3.8"},
+		{
+			Version:        "v1.2.0",
+			RequiresPython: ">=3.8",
+			Yanked:         true,
+			YankedReason:   "Security issue",
+		},
+	}
+
+	if !reflect.DeepEqual(infos, expected) {
+		t.Errorf("Expected releases %+v, but got %+v", expected, infos)
+	}
+}
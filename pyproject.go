@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+// pyprojectDocument represents the sections of a `pyproject.toml`
+// which are relevant to check the dependencies.
+type pyprojectDocument struct {
+	Project struct {
+		RequiresPython       string              `toml:"requires-python"`
+		Dependencies         []string            `toml:"dependencies"`
+		OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+	} `toml:"project"`
+	Tool struct {
+		Poetry *poetryDocument `toml:"poetry"`
+	} `toml:"tool"`
+}
+
+// ParsePyproject parses a `pyproject.toml` declaring its dependencies
+// according PEP 621 (https://peps.python.org/pep-0621/), and/or for Poetry.
+//
+// The `[project].dependencies` are returned as runtime dependencies,
+// and each group of `[project.optional-dependencies]` as a group of dependencies.
+// The `requires-python` field is returned as the required Python version.
+// The dependencies from `[tool.poetry]` are then merged (see parsePoetry).
+func ParsePyproject(reader io.Reader) (Pipfile, error) {
+	var document pyprojectDocument
+
+	if _, err := toml.NewDecoder(reader).Decode(&document); err != nil {
+		return Pipfile{}, err
+	}
+
+	project := document.Project
+
+	pipfile := Pipfile{
+		Sources:               []PipfileSource{},
+		Packages:              make(map[string]PipfilePackage),
+		DevPackages:           make(map[string]PipfilePackage),
+		RuntimeDependencies:   make(Dependencies),
+		DevDependencies:       make(Dependencies),
+		RequiresPythonVersion: VersionRequirement{},
+		GroupDependencies:     make(map[string]Dependencies),
+		GroupPackages:         make(map[string]map[string]PipfilePackage),
+	}
+
+	if project.RequiresPython != "" {
+		versionReq, err := ParseVersionRequirement(project.RequiresPython)
+
+		if err != nil {
+			return Pipfile{}, fmt.Errorf("requires-python: %s", err.Error())
+		}
+
+		pipfile.Requires.PythonVersion = project.RequiresPython
+		pipfile.RequiresPythonVersion = versionReq
+	}
+
+	var err error
+
+	pipfile.RuntimeDependencies, err = pyprojectDependencies(
+		project.Dependencies, pipfile.Packages)
+
+	if err != nil {
+		return Pipfile{}, err
+	}
+
+	for group, specs := range project.OptionalDependencies {
+		dependencies, err := pyprojectDependencies(specs, nil)
+
+		if err != nil {
+			return Pipfile{}, fmt.Errorf("%s: %s", group, err.Error())
+		}
+
+		pipfile.GroupDependencies[group] = dependencies
+	}
+
+	if poetry := document.Tool.Poetry; poetry != nil {
+		if err := parsePoetry(*poetry, &pipfile); err != nil {
+			return Pipfile{}, err
+		}
+	}
+
+	return pipfile, nil
+}
+
+// pyprojectDependencies resolves the version requirements
+// from the given list of PEP 508 specifications.
+// If packages is not nil, it's filled with the parsed specifications.
+func pyprojectDependencies(
+	specs []string,
+	packages map[string]PipfilePackage,
+) (Dependencies, error) {
+	dependencies := make(Dependencies)
+
+	for _, spec := range specs {
+		name, pkg, err := ParseRequirementSpec(spec)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "" {
+			continue
+		}
+
+		versionReq, err := ParseVersionRequirement(pkg.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if packages != nil {
+			packages[name] = pkg
+		}
+
+		dependencies[name] = versionReq
+	}
+
+	return dependencies, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePyproject(t *testing.T) {
+	file, err := os.Open("resources/pyproject.toml")
+
+	if err != nil {
+		t.Fatalf("Error occurred while loading fixture: %v", err)
+	}
+
+	defer file.Close()
+
+	result, err := ParsePyproject(file)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedRuntime := Dependencies{
+		"requests": VersionRequirement{
+			{">=", "v2.26.0"},
+			{"<", "v3"},
+		},
+		"python-dateutil": VersionRequirement{{"==", "v2.8.2"}},
+		"numpy":           VersionRequirement{{"*", "*"}},
+	}
+
+	if !reflect.DeepEqual(result.RuntimeDependencies, expectedRuntime) {
+		t.Errorf("Expected runtime dependencies: %v, got: %v", expectedRuntime, result.RuntimeDependencies)
+	}
+
+	if pkg := result.Packages["requests"]; !reflect.DeepEqual(pkg.Extras, []string{"socks"}) {
+		t.Errorf("Expected extras for requests: %v", pkg)
+	}
+
+	expectedGroups := map[string]Dependencies{
+		"test": {
+			"pytest":   VersionRequirement{{"~=", "v7.4"}},
+			"coverage": VersionRequirement{{"*", "*"}},
+		},
+		"Docs": {
+			"sphinx": VersionRequirement{{">=", "v7"}},
+		},
+	}
+
+	if !reflect.DeepEqual(result.GroupDependencies, expectedGroups) {
+		t.Errorf("Expected group dependencies: %v, got: %v", expectedGroups, result.GroupDependencies)
+	}
+
+	if len(result.DevDependencies) != 0 {
+		t.Errorf("Expected no dev dependency, got: %v", result.DevDependencies)
+	}
+
+	expectedPython := VersionRequirement{{">=", "v3.9"}}
+
+	if !reflect.DeepEqual(result.RequiresPythonVersion, expectedPython) {
+		t.Errorf("Expected Python requirement: %v, got: %v", expectedPython, result.RequiresPythonVersion)
+	}
+
+	if kind := GroupDependency("Docs"); kind.String() != "docs" {
+		t.Errorf("Expected dependency kind 'docs', got: %s", kind)
+	}
+}
+
+func TestParseInvalidPyproject(t *testing.T) {
+	tests := []string{
+		"[project]\ndependencies = \"requests\"\n",
+		"[project]\ndependencies = [\"requests==foo\"]\n",
+		"[project]\nrequires-python = \"foo\"\n",
+		"[project.optional-dependencies]\ntest = [\"!pytest\"]\n",
+	}
+
+	for _, input := range tests {
+		if _, err := ParsePyproject(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for pyproject: %s", input)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// ReleaseInfo represents a released version of a project.
+type ReleaseInfo struct {
+	// Version of the release (with `v` prefix)
+	Version string
+
+	// Python requirement of the release files, if any
+	RequiresPython string
+
+	// Whether the release has been yanked (see PEP 592)
+	Yanked bool
+
+	// Reason why the release has been yanked, if any
+	YankedReason string
+
+	// URL of the release page, if specific to the release
+	// (e.g. a Gitlab package), otherwise the home URL of the project is used
+	Url string
+}
+
+// FindRelease returns the release for the given version
+// (e.g. `1.0` for `v1.0.0`), or nil if not found.
+func FindRelease(releases []ReleaseInfo, version string) *ReleaseInfo {
+	v, err := ParseVersion(version)
+
+	if err != nil {
+		return nil
+	}
+
+	for i, release := range releases {
+		rv, err := ParseVersion(release.Version)
+
+		if err == nil && rv.Compare(v) == 0 {
+			return &releases[i]
+		}
+	}
+
+	return nil
+}
+
+// LatestRelease returns the release with the highest version
+// among the ones accepted by the given function,
+// or nil if none is accepted.
+// The releases with an invalid version are ignored.
+func LatestRelease(
+	releases []ReleaseInfo,
+	accept func(release ReleaseInfo, version Version) bool,
+) *ReleaseInfo {
+	var latest *ReleaseInfo
+	var latestVersion Version
+
+	for i, release := range releases {
+		v, err := ParseVersion(release.Version)
+
+		if err != nil {
+			log.Debugf("Ignoring invalid version: %s", release.Version)
+
+			continue
+		}
+
+		if latest != nil && v.Compare(latestVersion) <= 0 {
+			continue
+		}
+
+		if accept(release, v) {
+			latest = &releases[i]
+			latestVersion = v
+		}
+	}
+
+	return latest
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLatestRelease(t *testing.T) {
+	releases := []ReleaseInfo{
+		{Version: "v1.0.0"},
+		{Version: "v1.10.0", RequiresPython: ">=3.8"},
+		{Version: "v1.9.0"},
+		{Version: "v2.0.0rc1"},
+		{Version: "invalid"},
+	}
+
+	latest := LatestRelease(releases, func(ReleaseInfo, Version) bool { return true })
+
+	if latest == nil || latest.Version != "v2.0.0rc1" {
+		t.Errorf("Expected latest release v2.0.0rc1, but got %v", latest)
+	}
+
+	stable := LatestRelease(releases, func(_ ReleaseInfo, v Version) bool {
+		return !v.IsPreRelease()
+	})
+
+	if stable == nil || stable.Version != "v1.10.0" || stable.RequiresPython != ">=3.8" {
+		t.Errorf("Expected latest stable release v1.10.0, but got %v", stable)
+	}
+
+	if none := LatestRelease(releases, func(ReleaseInfo, Version) bool { return false }); none != nil {
+		t.Errorf("Expected no release, but got %v", none)
+	}
+}
@@ -8,35 +8,116 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// PackageUpdate represents the outcome of the check for a package.
+type PackageUpdate struct {
+	// Name of the package being reported
+	PackageName string
+
+	// Version requirement for the package (e.g. from the Pipfile)
+	Requirement VersionRequirement
+
+	// Version locked for the package (e.g. from the Pipfile.lock), if any
+	LockedVersion string
+
+	// Latest version available for the package
+	LatestVersion string
+
+	// Latest version which can be installed for the package
+	// (e.g. compatible with the required Python version), if any
+	InstallableVersion string
+
+	// Level of update available for the package
+	UpdateLevel UpdateLevel
+
+	// Kind of dependency for the package
+	DependencyKind DependencyKind
+
+	// URL of the package
+	PackageUrl string
+
+	// Name of the registry where the package has been found (e.g. `pypi`)
+	Registry string
+
+	// Whether the pinned or locked version has been yanked
+	Yanked bool
+
+	// Reason why the pinned or locked version has been yanked, if any
+	YankedReason string
+
+	// Dependency confusion found for the package, if any
+	Confusion *DependencyConfusion
+
+	// Whether the package is unknown to all the registries
+	NotFound bool
+
+	// Error which occurred while checking the package, if any
+	// (then the versions are unknown)
+	Error error
+
+	// Whether the update is fatal
+	Fatal bool
+
+	// Duration in seconds to check the package
+	TimeSec float64
+}
+
+// IsPreRelease returns true if the installable version is a pre-release.
+func (u PackageUpdate) IsPreRelease() bool {
+	v, err := ParseVersion(u.InstallableVersion)
+
+	return err == nil && v.IsPreRelease()
+}
+
+// Notes returns the remarks about the update, each one between parenthesis
+// (e.g. ` (pre-release)` if the installable version is a pre-release,
+// ` (yanked: reason)` if the pinned version has been yanked,
+// or ` (dependency confusion: registries)` if found on several registries),
+// or an empty string if there is none.
+func (u PackageUpdate) Notes() string {
+	notes := ""
+
+	if u.IsPreRelease() {
+		notes += " (pre-release)"
+	}
+
+	if u.Yanked {
+		if u.YankedReason != "" {
+			notes += fmt.Sprintf(" (yanked: %s)", u.YankedReason)
+		} else {
+			notes += " (yanked)"
+		}
+	}
+
+	if c := u.Confusion; c != nil {
+		if c.IsCritical() {
+			notes += fmt.Sprintf(" (critical dependency confusion: %s)", c)
+		} else {
+			notes += fmt.Sprintf(" (dependency confusion: %s)", c)
+		}
+	}
+
+	return notes
+}
+
 type UpdateReporter interface {
 	// Returns a reporting name
 	ReporterName() string
 
 	Before(out io.Writer)
 
+	// BeginGroup starts a group of updates (e.g. `major` when grouped by level),
+	// when the updates are grouped.
+	BeginGroup(group string, out io.Writer)
+
 	// Report an update for a package.
 	// Arguments:
-	// - packageName: string representing the name of the package being reported.
-	// - requirement: VersionRequirement representing the version requirement for the package.
-	// - latestVersion: string representing the latest version available for the package.
-	// - updateLevel: UpdateLevel representing the level of update available for the package.
-	// - dependencyKind: DependencyKind representing the kind of dependency for the package.
-	// - packageUrl: string representing the URL of the package.
-	// - fatal: boolean indicating whether the package is a fatal dependency.
+	// - update: PackageUpdate representing the update to be reported.
 	// - excludedPackages: slice of strings representing the names of packages to be excluded from the report.
-	// - timeSec: duration in seconds to check the package.
 	// - out: io.Writer representing the output writer to which the report will be written.
 	// Returns an error if any.
 	Report(
-		packageName string,
-		requirement VersionRequirement,
-		latestVersion string,
-		updateLevel UpdateLevel,
-		dependencyKind DependencyKind,
-		packageUrl string,
-		fatal bool,
+		update PackageUpdate,
 		excludedPackages []string,
-		timeSec float64,
 		out io.Writer,
 	) error
 
@@ -50,6 +131,17 @@ type TextReporter struct {
 	MessageBefore string
 	Pattern       string
 	MessageAfter  string
+
+	// Pattern applied with the group name at the beginning of a group, if any
+	GroupPattern string
+
+	// Pattern applied instead of Pattern for a package which cannot be checked
+	// (see PackageUpdate.Error), if any
+	ErrorPattern string
+
+	// Pattern applied instead of Pattern for a package unknown to all the registries
+	// (see PackageUpdate.NotFound), if any
+	NotFoundPattern string
 }
 
 func (r TextReporter) ReporterName() string {
@@ -61,46 +153,72 @@ func (r TextReporter) Before(out io.Writer) {
 	fmt.Fprint(out, r.MessageBefore)
 }
 
+// BeginGroup is a method of the UpdateReporter interface. It writes the GroupPattern field of the TextReporter struct, applied with the group name, to the output writer.
+func (r TextReporter) BeginGroup(group string, out io.Writer) {
+	if r.GroupPattern != "" {
+		fmt.Fprintf(out, r.GroupPattern, group)
+	}
+}
+
 // Report is a method of the UpdateReporter interface. It formats the output of the report in a text format and writes it to the output writer.
+//
+// The Pattern is applied with the following arguments:
+// package name, requirement, latest version, update level,
+// dependency kind, time in seconds, package URL, locked version,
+// installable version and notes (see PackageUpdate.Notes).
+// If the package cannot be checked, the ErrorPattern (if any) is applied instead,
+// with the error message as additional argument,
+// and if the package is not found, the NotFoundPattern (if any) is applied instead.
 func (r TextReporter) Report(
-	packageName string,
-	requirement VersionRequirement,
-	latestVersion string,
-	updateLevel UpdateLevel,
-	dependencyKind DependencyKind,
-	packageUrl string,
-	fatal bool,
+	update PackageUpdate,
 	excludedPackages []string,
-	timeSec float64,
 	out io.Writer,
 ) error {
-	if ContainsString(excludedPackages, packageName) {
-		log.Debugf("skipping package %s", packageName)
+	if ContainsString(excludedPackages, update.PackageName) {
+		log.Debugf("skipping package %s", update.PackageName)
 
 		return nil
 	}
 
 	// ---
 
+	args := []interface{}{
+		update.PackageName,
+		FormatRequirement(update.Requirement),
+		update.LatestVersion,
+		update.UpdateLevel,
+		update.DependencyKind,
+		update.TimeSec,
+		update.PackageUrl,
+		update.LockedVersion,
+		update.InstallableVersion,
+		update.Notes(),
+	}
+
+	pattern := r.Pattern
+
+	if update.Error != nil && r.ErrorPattern != "" {
+		pattern = r.ErrorPattern
+		args = append(args, update.Error.Error())
+	} else if update.NotFound && r.NotFoundPattern != "" {
+		pattern = r.NotFoundPattern
+	}
+
+	fmt.Fprintf(out, pattern, args...)
+
+	return nil
+}
+
+// FormatRequirement returns the textual representation of a version requirement
+// (e.g. `>=1.0.0, <2.0.0`).
+func FormatRequirement(requirement VersionRequirement) string {
 	reqs := make([]string, len(requirement))
 
 	for i, req := range requirement {
 		reqs[i] = fmt.Sprintf("%s%s", req[0], req[1])
 	}
 
-	fmt.Fprintf(
-		out,
-		r.Pattern,
-		packageName,
-		strings.Join(reqs, ", "),
-		latestVersion,
-		updateLevel,
-		dependencyKind,
-		timeSec,
-		packageUrl,
-	)
-
-	return nil
+	return strings.Join(reqs, ", ")
 }
 
 // After is a method of the UpdateReporter interface. It writes the MessageAfter field of the TextReporter struct to the output writer.
@@ -115,13 +233,19 @@ const MonochromeTableReporterName = "monochrome-table"
 // MonochromeTableReporter returns a TextReporter that formats the output of the report in a monochrome table format.
 // The function takes a version string as input and returns a TextReporter struct with MessageBefore, Pattern, and MessageAfter fields.
 // The MessageBefore field contains a formatted string with the version number and column headers.
-// The Pattern field contains a formatted string with placeholders for package name, wanted version, latest version, package type, and details.
+// The Pattern field contains a formatted string with placeholders for package name, wanted version, locked version, latest version, installable version, package type, and details.
 // The MessageAfter field is an empty string.
+// The GroupPattern field contains the group name between brackets.
+// The ErrorPattern field contains the error message instead of the latest version and details.
+// The NotFoundPattern field indicates that the package is not found instead of the details.
 func MonochromeTableReporter(version string) TextReporter {
 	return TextReporter{
-		Name:          MonochromeTableReporterName,
-		MessageBefore: fmt.Sprintf("-- wilf v%s --\nPackage         Wanted          Latest      Package type  Details\n", version),
-		Pattern:       "%-14.14[1]s\t%-12.12[2]s\t%-12.12[3]s%-12.12[5]s  %[4]s for %[1]s; %[7]s\n",
-		MessageAfter:  "",
+		Name:            MonochromeTableReporterName,
+		MessageBefore:   fmt.Sprintf("-- wilf v%s --\nPackage         Wanted          Locked      Latest      Installable Package type  Details\n", version),
+		Pattern:         "%-14.14[1]s\t%-12.12[2]s\t%-12.12[8]s%-12.12[3]s%-12.12[9]s%-12.12[5]s  %[4]s for %[1]s%[10]s; %[7]s\n",
+		MessageAfter:    "",
+		GroupPattern:    "\n[%s]\n",
+		ErrorPattern:    "%-14.14[1]s\t%-12.12[2]s\t%-12.12[8]s%-12.12[3]s%-12.12[9]s%-12.12[5]s  error for %[1]s: %[11]s\n",
+		NotFoundPattern: "%-14.14[1]s\t%-12.12[2]s\t%-12.12[8]s%-12.12[3]s%-12.12[9]s%-12.12[5]s  %[1]s not found on any registry\n",
 	}
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -13,7 +14,9 @@ func TestTextReporter(t *testing.T) {
 		reporter       TextReporter
 		packageName    string
 		requirement    VersionRequirement
+		lockedVersion  string
 		latestVersion  string
+		installable    string
 		updateLevel    UpdateLevel
 		dependencyKind DependencyKind
 		packageUrl     string
@@ -24,32 +27,35 @@ func TestTextReporter(t *testing.T) {
 			reporter: TextReporter{
 				Name:          "reporter1",
 				MessageBefore: "",
-				Pattern:       "%s %s %s %s %s %.0f %s",
+				Pattern:       "%s %s %s %s %s %.0f %s %s %s%s",
 				MessageAfter:  "",
 			},
 			packageName:    "github.com/test/package",
 			requirement:    VersionRequirement{{">=", "1.0.0"}},
+			lockedVersion:  "1.0.0",
 			latestVersion:  "2.0.0",
+			installable:    "1.9.0",
 			updateLevel:    Major,
 			dependencyKind: DevDependency,
 			packageUrl:     "https://github.com/test/package",
-			expectedOutput: "github.com/test/package >=1.0.0 2.0.0 major dev 0 https://github.com/test/package",
+			expectedOutput: "github.com/test/package >=1.0.0 2.0.0 major dev 0 https://github.com/test/package 1.0.0 1.9.0",
 		},
 		{
 			name: "Test case 2",
 			reporter: TextReporter{
 				Name:          "reporter2",
 				MessageBefore: "",
-				Pattern:       "%s %s %s %s %s %.0f %s\n",
+				Pattern:       "%s %s %s %s %s %.0f %s %s %s%s\n",
 				MessageAfter:  "",
 			},
 			packageName:    "github.com/test/package",
 			requirement:    VersionRequirement{{">=", "1.0.0"}, {"<", "2.0.0"}},
 			latestVersion:  "1.5.0",
+			installable:    "1.5.0rc1",
 			updateLevel:    Patch,
 			dependencyKind: RunDependency,
 			packageUrl:     "https://github.com/test/package",
-			expectedOutput: "github.com/test/package >=1.0.0, <2.0.0 1.5.0 patch runtime 0 https://github.com/test/package\n",
+			expectedOutput: "github.com/test/package >=1.0.0, <2.0.0 1.5.0 patch runtime 0 https://github.com/test/package  1.5.0rc1 (pre-release)\n",
 		},
 	}
 
@@ -62,15 +68,19 @@ func TestTextReporter(t *testing.T) {
 			}
 
 			err := tc.reporter.Report(
-				tc.packageName,
-				tc.requirement,
-				tc.latestVersion,
-				tc.updateLevel,
-				tc.dependencyKind,
-				tc.packageUrl,
-				false,
+				PackageUpdate{
+					PackageName:        tc.packageName,
+					Requirement:        tc.requirement,
+					LockedVersion:      tc.lockedVersion,
+					LatestVersion:      tc.latestVersion,
+					InstallableVersion: tc.installable,
+					UpdateLevel:        tc.updateLevel,
+					DependencyKind:     tc.dependencyKind,
+					PackageUrl:         tc.packageUrl,
+					Fatal:              false,
+					TimeSec:            0,
+				},
 				[]string{},
-				0,
 				&buf,
 			)
 
@@ -90,15 +100,19 @@ func TestTextReporter(t *testing.T) {
 		t.Run(fmt.Sprintf("%sExcluded", tc.name), func(t *testing.T) {
 			var buf bytes.Buffer
 			err := tc.reporter.Report(
-				tc.packageName,
-				tc.requirement,
-				tc.latestVersion,
-				tc.updateLevel,
-				tc.dependencyKind,
-				tc.packageUrl,
-				false,
+				PackageUpdate{
+					PackageName:        tc.packageName,
+					Requirement:        tc.requirement,
+					LockedVersion:      tc.lockedVersion,
+					LatestVersion:      tc.latestVersion,
+					InstallableVersion: tc.installable,
+					UpdateLevel:        tc.updateLevel,
+					DependencyKind:     tc.dependencyKind,
+					PackageUrl:         tc.packageUrl,
+					Fatal:              false,
+					TimeSec:            0,
+				},
 				[]string{tc.packageName},
-				0,
 				&buf,
 			)
 
@@ -124,17 +138,109 @@ func TestMonochromeTableReporter(t *testing.T) {
 
 	reporter.Before(&buf)
 
-	reporter.Report("github.com/user/repo",
-		VersionRequirement{{">=", "1.0.0"}}, "1.2.3",
-		Patch, RunDependency, "https://github.com/user/repo", false, []string{}, 0, &buf)
+	reporter.BeginGroup("patch", &buf)
+
+	reporter.Report(
+		PackageUpdate{
+			PackageName:        "github.com/user/repo",
+			Requirement:        VersionRequirement{{">=", "1.0.0"}},
+			LockedVersion:      "1.0.1",
+			LatestVersion:      "1.3.0",
+			InstallableVersion: "1.2.3",
+			UpdateLevel:        Patch,
+			DependencyKind:     RunDependency,
+			PackageUrl:         "https://github.com/user/repo",
+			Fatal:              false,
+			TimeSec:            0,
+		},
+		[]string{},
+		&buf,
+	)
+
+	reporter.Report(
+		PackageUpdate{
+			PackageName:    "requests",
+			Requirement:    VersionRequirement{{">=", "2.0.0"}},
+			LockedVersion:  "2.31.0",
+			DependencyKind: RunDependency,
+			Error:          errors.New("connection refused"),
+		},
+		[]string{},
+		&buf,
+	)
+
+	reporter.Report(
+		PackageUpdate{
+			PackageName:    "reqeusts",
+			Requirement:    VersionRequirement{{">=", "2.0.0"}},
+			DependencyKind: RunDependency,
+			NotFound:       true,
+		},
+		[]string{},
+		&buf,
+	)
 
 	reporter.After(&buf)
 
 	// Package name "github.com/user/repo" is truncated to "github.com/use" because of the width of the terminal
-	expected := "-- wilf v1.0.0 --\nPackage         Wanted          Latest      Package type  Details\n" +
-		"github.com/use\t>=1.0.0     \t1.2.3       runtime       patch for github.com/user/repo; https://github.com/user/repo\n"
+	expected := "-- wilf v1.0.0 --\nPackage         Wanted          Locked      Latest      Installable Package type  Details\n" +
+		"\n[patch]\n" +
+		"github.com/use\t>=1.0.0     \t1.0.1       1.3.0       1.2.3       runtime       patch for github.com/user/repo; https://github.com/user/repo\n" +
+		"requests      \t>=2.0.0     \t2.31.0                              runtime       error for requests: connection refused\n" +
+		"reqeusts      \t>=2.0.0     \t                                    runtime       reqeusts not found on any registry\n"
 
 	if buf.String() != expected {
 		t.Errorf("Unexpected output:\nExpected: %s\nGot     : %s", expected, buf.String())
 	}
 }
+
+func TestPackageUpdateNotes(t *testing.T) {
+	tests := []struct {
+		update   PackageUpdate
+		expected string
+	}{
+		{PackageUpdate{InstallableVersion: "v1.0.0"}, ""},
+		{PackageUpdate{InstallableVersion: "v1.0.0b2"}, " (pre-release)"},
+		{PackageUpdate{InstallableVersion: "v1.0.0", Yanked: true}, " (yanked)"},
+		{
+			PackageUpdate{
+				InstallableVersion: "v1.1.0.dev1",
+				Yanked:             true,
+				YankedReason:       "Security issue",
+			},
+			" (pre-release) (yanked: Security issue)",
+		},
+		{
+			PackageUpdate{
+				InstallableVersion: "v1.0.0",
+				Confusion: &DependencyConfusion{
+					Level: ConfusionWarning,
+					Registries: []RegistryVersion{
+						{Registry: "gitlab", Version: "v1.1.0"},
+						{Registry: "pypi", Version: "v1.0.0", Public: true},
+					},
+				},
+			},
+			" (dependency confusion: gitlab 1.1.0, pypi 1.0.0)",
+		},
+		{
+			PackageUpdate{
+				InstallableVersion: "v1.0.0",
+				Confusion: &DependencyConfusion{
+					Level: ConfusionCritical,
+					Registries: []RegistryVersion{
+						{Registry: "gitlab", Version: "v1.0.0"},
+						{Registry: "pypi", Version: "v99.0.0", Public: true},
+					},
+				},
+			},
+			" (critical dependency confusion: gitlab 1.0.0, pypi 99.0.0)",
+		},
+	}
+
+	for _, test := range tests {
+		if notes := test.update.Notes(); notes != test.expected {
+			t.Errorf("Expected notes '%s', but got '%s'", test.expected, notes)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	requirementComment = regexp.MustCompile(`(^|\s)#.*$`)
+	requirementLine    = regexp.MustCompile(
+		`^([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)\s*(?:\[([^\]]*)\])?\s*(.*)$`)
+)
+
+// requirementsParser accumulates the content
+// of a requirements file and of its included files.
+type requirementsParser struct {
+	pipfile     Pipfile
+	constraints Dependencies
+	visited     map[string]bool
+}
+
+// ParseRequirementsFile parses a pip requirements file
+// (see https://pip.pypa.io/en/stable/reference/requirements-file-format/),
+// and returns the corresponding model, with the requirements as runtime dependencies.
+//
+// The files included with `-r` (requirements) or `-c` (constraints)
+// are resolved relatively to the including file.
+// The constraints only apply to the packages which are required.
+// The `--index-url` and `--extra-index-url` options are returned as sources,
+// all looked up as pip does (with PyPI as main index if not overridden).
+func ParseRequirementsFile(path string) (Pipfile, error) {
+	parser := requirementsParser{
+		pipfile: Pipfile{
+			Sources:               []PipfileSource{},
+			Packages:              make(map[string]PipfilePackage),
+			DevPackages:           make(map[string]PipfilePackage),
+			RuntimeDependencies:   make(Dependencies),
+			DevDependencies:       make(Dependencies),
+			RequiresPythonVersion: VersionRequirement{},
+		},
+		constraints: make(Dependencies),
+		visited:     make(map[string]bool),
+	}
+
+	if err := parser.parseFile(path, false); err != nil {
+		return Pipfile{}, err
+	}
+
+	parser.pipfile.Pipenv.InstallSearchAllSources = true
+
+	if sources := parser.pipfile.Sources; len(sources) > 0 && sources[0].Name != "index" {
+		// Only extra indexes
+		parser.addSource("index", DefaultPypiSimpleUrl, true)
+	}
+
+	for name, requirement := range parser.pipfile.RuntimeDependencies {
+		constraint, ok := parser.constraints[NormalizePackageName(name)]
+
+		if !ok {
+			continue
+		}
+
+		if len(requirement) == 1 && requirement[0][0] == "*" {
+			requirement = VersionRequirement{}
+		}
+
+		requirement = append(requirement, constraint...)
+
+		if r, err := NewVersionRange(requirement); err == nil && r.IsEmpty() {
+			log.Warnf("No version of %s can match the constraints: %s",
+				name, FormatRequirement(requirement))
+		}
+
+		parser.pipfile.RuntimeDependencies[name] = requirement
+	}
+
+	return parser.pipfile, nil
+}
+
+func (p *requirementsParser) parseFile(path string, constraint bool) error {
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		return err
+	}
+
+	if p.visited[abs] {
+		log.Debugf("Ignoring already included file: %s", path)
+
+		return nil
+	}
+
+	p.visited[abs] = true
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if err := p.parse(file, filepath.Dir(path), constraint); err != nil {
+		return fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	return nil
+}
+
+func (p *requirementsParser) parse(
+	reader io.Reader,
+	dir string,
+	constraint bool,
+) error {
+	scanner := bufio.NewScanner(reader)
+	line := ""
+
+	for scanner.Scan() {
+		// Join continued lines
+		part := scanner.Text()
+
+		if strings.HasSuffix(part, "\\") {
+			line += strings.TrimSuffix(part, "\\")
+			continue
+		}
+
+		line += part
+
+		// Remove comment
+		line = strings.TrimSpace(requirementComment.ReplaceAllString(line, ""))
+
+		if line != "" {
+			if err := p.parseLine(line, dir, constraint); err != nil {
+				return err
+			}
+		}
+
+		line = ""
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	line = strings.TrimSpace(requirementComment.ReplaceAllString(line, ""))
+
+	if line != "" {
+		return p.parseLine(line, dir, constraint)
+	}
+
+	return nil
+}
+
+func (p *requirementsParser) parseLine(
+	line string,
+	dir string,
+	constraint bool,
+) error {
+	if strings.HasPrefix(line, "-") {
+		return p.parseOption(line, dir)
+	}
+
+	// Remove the per-requirement options (e.g. `--hash=...`)
+	if i := strings.Index(line, " --"); i != -1 {
+		line = strings.TrimSpace(line[:i])
+	}
+
+	name, pkg, err := ParseRequirementSpec(line)
+
+	if err != nil || name == "" {
+		return err
+	}
+
+	versionReq, err := ParseVersionRequirement(pkg.Version)
+
+	if err != nil {
+		return err
+	}
+
+	if constraint {
+		key := NormalizePackageName(name)
+
+		p.constraints[key] = append(p.constraints[key], versionReq...)
+
+		return nil
+	}
+
+	p.pipfile.Packages[name] = pkg
+	p.pipfile.RuntimeDependencies[name] = versionReq
+
+	return nil
+}
+
+// ParseRequirementSpec parses a PEP 508 requirement specification
+// (e.g. `requests[socks] >= 2.26.0, < 3 ; python_version >= "3.8"`),
+// and returns the package name with the corresponding package specification.
+// The requirements referring to a URL or a local path are ignored,
+// with an empty name returned.
+func ParseRequirementSpec(spec string) (string, PipfilePackage, error) {
+	line := strings.TrimSpace(spec)
+
+	// Split environment markers
+	markers := ""
+
+	if i := strings.Index(line, ";"); i != -1 {
+		markers = strings.TrimSpace(line[i+1:])
+		line = strings.TrimSpace(line[:i])
+	}
+
+	if strings.Contains(line, "://") || strings.Contains(line, "/") ||
+		strings.HasPrefix(line, ".") {
+		log.Debugf("Ignoring requirement from URL or path: %s", line)
+
+		return "", PipfilePackage{}, nil
+	}
+
+	match := requirementLine.FindStringSubmatch(line)
+
+	if match == nil {
+		return "", PipfilePackage{}, fmt.Errorf("invalid requirement: %s", line)
+	}
+
+	version := strings.Join(strings.Fields(match[3]), "")
+
+	if strings.HasPrefix(version, "@") {
+		log.Debugf("Ignoring requirement with direct reference: %s", line)
+
+		return "", PipfilePackage{}, nil
+	}
+
+	version = strings.TrimSuffix(strings.TrimPrefix(version, "("), ")")
+
+	if version == "" {
+		version = "*"
+	}
+
+	var extras []string
+
+	if match[2] != "" {
+		for _, extra := range strings.Split(match[2], ",") {
+			extras = append(extras, strings.TrimSpace(extra))
+		}
+	}
+
+	return match[1], PipfilePackage{
+		Version: version,
+		Extras:  extras,
+		Markers: markers,
+	}, nil
+}
+
+func (p *requirementsParser) parseOption(line string, dir string) error {
+	option := line
+	value := ""
+
+	if i := strings.IndexAny(line, " \t="); i != -1 {
+		option = line[:i]
+		value = strings.TrimSpace(strings.TrimLeft(line[i:], " \t="))
+	}
+
+	switch option {
+	case "-r", "--requirement", "-c", "--constraint":
+		if value == "" {
+			return fmt.Errorf("missing file for option: %s", line)
+		}
+
+		path := value
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		constraint := option == "-c" || option == "--constraint"
+
+		return p.parseFile(path, constraint)
+
+	case "-i", "--index-url":
+		p.addSource("index", value, true)
+
+	case "--extra-index-url":
+		p.addSource(fmt.Sprintf("extra-%d", len(p.pipfile.Sources)), value, false)
+
+	case "--pre":
+		p.pipfile.Pipenv.AllowPrereleases = true
+
+	default:
+		log.Debugf("Ignoring requirements option: %s", line)
+	}
+
+	return nil
+}
+
+// addSource registers an index URL as a source,
+// in first position for the main index.
+func (p *requirementsParser) addSource(name string, url string, main bool) {
+	source := PipfileSource{
+		Name:      name,
+		Url:       url,
+		VerifySsl: true,
+	}
+
+	if !main {
+		p.pipfile.Sources = append(p.pipfile.Sources, source)
+
+		return
+	}
+
+	sources := []PipfileSource{source}
+
+	for _, s := range p.pipfile.Sources {
+		if s.Name != name {
+			sources = append(sources, s)
+		}
+	}
+
+	p.pipfile.Sources = sources
+}
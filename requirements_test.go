@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRequirementsFile(t *testing.T) {
+	result, err := ParseRequirementsFile("resources/requirements/requirements.txt")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedSources := []PipfileSource{
+		{
+			Name:      "index",
+			Url:       "https://pypi.example.com/simple",
+			VerifySsl: true,
+		},
+		{
+			Name:      "extra-1",
+			Url:       "https://pypi.org/simple",
+			VerifySsl: true,
+		},
+	}
+
+	if !reflect.DeepEqual(result.Sources, expectedSources) {
+		t.Errorf("Expected sources: %v, got: %v", expectedSources, result.Sources)
+	}
+
+	expectedPackages := map[string]PipfilePackage{
+		"python-dateutil": {Version: "==2.8.2"},
+		"requests": {
+			Version: ">=2.26.0,<3",
+			Extras:  []string{"socks", "security"},
+			Markers: `python_version >= "3.8"`,
+		},
+		"Django": {Version: "~=4.2"},
+		"numpy":  {Version: "*"},
+		"flask":  {Version: ">=2.0"},
+	}
+
+	if !reflect.DeepEqual(result.Packages, expectedPackages) {
+		t.Errorf("Expected packages: %v, got: %v", expectedPackages, result.Packages)
+	}
+
+	expectedDependencies := Dependencies{
+		"python-dateutil": VersionRequirement{{"==", "v2.8.2"}},
+		"requests": VersionRequirement{
+			{">=", "v2.26.0"},
+			{"<", "v3"},
+			{"<", "v2.32"},
+		},
+		"Django": VersionRequirement{{"~=", "v4.2"}},
+		"numpy":  VersionRequirement{{"==", "v1.26.4"}},
+		"flask":  VersionRequirement{{">=", "v2.0"}},
+	}
+
+	if !reflect.DeepEqual(result.RuntimeDependencies, expectedDependencies) {
+		t.Errorf("Expected dependencies: %v, got: %v", expectedDependencies, result.RuntimeDependencies)
+	}
+
+	if len(result.DevDependencies) != 0 {
+		t.Errorf("Expected no dev dependency, got: %v", result.DevDependencies)
+	}
+
+	if !result.Pipenv.AllowPrereleases {
+		t.Errorf("Expected pre-releases to be allowed")
+	}
+
+	if !result.Pipenv.InstallSearchAllSources {
+		t.Errorf("Expected all the sources to be searched")
+	}
+}
+
+func TestParseInvalidRequirementsFile(t *testing.T) {
+	_, err := ParseRequirementsFile("resources/requirements/missing.txt")
+
+	if err == nil {
+		t.Errorf("Expected error for missing requirements file")
+	}
+
+	_, err = ParseRequirementsFile("resources/valid1.pipfile")
+
+	if err == nil {
+		t.Errorf("Expected error for invalid requirements file")
+	}
+}
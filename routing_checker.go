@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// RoutingChecker checks each package only against the registries
+// of its declared index (e.g. `index = "private"` in a Pipfile),
+// or of the first pattern matching its name (see RegistryMapping),
+// so that a private package is never looked up on another index.
+type RoutingChecker struct {
+	// Checkers for the packages without declared index nor matching pattern
+	Default Checker
+
+	// Checkers by normalized package name
+	Routes map[string]Checker
+
+	// Checkers for the packages matching the patterns, in order
+	Patterns []PatternRoute
+}
+
+// PatternRoute is the route for the packages whose normalized name
+// matches the pattern (e.g. `acme-*`).
+type PatternRoute struct {
+	Pattern string
+	Checker Checker
+}
+
+// RegistryMapping maps the packages whose name matches the pattern
+// (e.g. `acme-*`, see path.Match) to a registry: either `pypi`, `gitlab`,
+// or the name of a source of the dependency file.
+type RegistryMapping struct {
+	Pattern  string `toml:"pattern"`
+	Registry string `toml:"registry"`
+}
+
+// Validate checks that the pattern is valid and that the registry is specified.
+func (m RegistryMapping) Validate() error {
+	if _, err := path.Match(m.Pattern, ""); err != nil || m.Pattern == "" {
+		return fmt.Errorf("invalid registry mapping pattern: '%s'", m.Pattern)
+	}
+
+	if m.Registry == "" {
+		return fmt.Errorf("missing registry for pattern: '%s'", m.Pattern)
+	}
+
+	return nil
+}
+
+// Matches checks whether the name of the package matches the pattern,
+// both being normalized (see NormalizePackageName).
+func (r PatternRoute) Matches(pkg string) bool {
+	matches, _ := path.Match(NormalizePackageName(r.Pattern), NormalizePackageName(pkg))
+
+	return matches
+}
+
+// RequiredUpdate checks the package using the checker of its route,
+// then of the first matching pattern, or the default one.
+func (c RoutingChecker) RequiredUpdate(
+	pkg string,
+	requirement VersionRequirement,
+) (CheckResult, error) {
+	if checker, ok := c.Routes[NormalizePackageName(pkg)]; ok {
+		return checker.RequiredUpdate(pkg, requirement)
+	}
+
+	for _, route := range c.Patterns {
+		if route.Matches(pkg) {
+			return route.Checker.RequiredUpdate(pkg, requirement)
+		}
+	}
+
+	return c.Default.RequiredUpdate(pkg, requirement)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Media types of the Simple Repository API (see PEP 691)
+const (
+	simpleJsonMediaType = "application/vnd.pypi.simple.v1+json"
+	simpleHtmlMediaType = "application/vnd.pypi.simple.v1+html"
+)
+
+// simpleFile represents a distribution file of a project
+// in the Simple Repository API.
+type simpleFile struct {
+	Filename       string `json:"filename"`
+	Url            string `json:"url"`
+	RequiresPython string `json:"requires-python"`
+
+	// Either a boolean or the reason why the file has been yanked (see PEP 592)
+	Yanked interface{} `json:"yanked"`
+}
+
+var (
+	simpleAnchor    = regexp.MustCompile(`(?is)<a\s([^>]*)>(.*?)</a>`)
+	simpleAttribute = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	sdistExtensions = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tar.Z", ".tgz", ".tbz", ".zip", ".tar"}
+)
+
+// GetSimpleProjectInfo retrieves information about a project
+// from an index implementing the Simple Repository API
+// (in either the HTML or the JSON format; see PEP 503 and PEP 691),
+// using the given HTTP client (or the default one if nil).
+//
+// The released versions are derived from the distribution filenames.
+// If the package is not found, it returns nil and no error.
+func GetSimpleProjectInfo(
+	client *http.Client,
+	indexUrl string,
+	packageName string,
+) (*ProjectInfo, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	projectUrl := fmt.Sprintf("%s/%s/",
+		strings.TrimSuffix(indexUrl, "/"), NormalizePackageName(packageName))
+
+	request, err := http.NewRequest("GET", projectUrl, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", fmt.Sprintf(
+		"%s, %s;q=0.2, text/html;q=0.01", simpleJsonMediaType, simpleHtmlMediaType))
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if err := checkTransientStatus(projectUrl, response); err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fails to get project information from %s: %s",
+			redactedUrl(projectUrl), response.Status)
+	}
+
+	body, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []simpleFile
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+
+	if mediaType == simpleJsonMediaType || mediaType == "application/json" {
+		files, err = parseSimpleJson(body)
+	} else {
+		files = parseSimpleHtml(body)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid project information from %s: %s",
+			redactedUrl(projectUrl), err.Error())
+	}
+
+	releases := simpleReleases(packageName, files)
+
+	info := ProjectInfo{
+		Name:     packageName,
+		HomeURL:  redactedUrl(projectUrl),
+		Releases: releases,
+	}
+
+	// As the JSON API, the latest version is the latest final release if any
+	latest := LatestRelease(releases, func(release ReleaseInfo, v Version) bool {
+		return !release.Yanked && !v.IsPreRelease()
+	})
+
+	if latest == nil {
+		latest = LatestRelease(releases, func(ReleaseInfo, Version) bool { return true })
+	}
+
+	if latest != nil {
+		info.Version = latest.Version
+		info.RequiresPython = latest.RequiresPython
+	}
+
+	return &info, nil
+}
+
+// parseSimpleJson returns the files of the project page
+// in the JSON format of the Simple Repository API (see PEP 691).
+func parseSimpleJson(body []byte) ([]simpleFile, error) {
+	var page struct {
+		Files []simpleFile `json:"files"`
+	}
+
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+
+	return page.Files, nil
+}
+
+// parseSimpleHtml returns the files of the project page
+// in the HTML format of the Simple Repository API (see PEP 503),
+// from the anchors and their `data-requires-python`
+// and `data-yanked` attributes.
+func parseSimpleHtml(body []byte) []simpleFile {
+	var files []simpleFile
+
+	for _, anchor := range simpleAnchor.FindAllSubmatch(body, -1) {
+		file := simpleFile{
+			Filename: strings.TrimSpace(html.UnescapeString(string(anchor[2]))),
+		}
+
+		for _, attr := range simpleAttribute.FindAllSubmatch(anchor[1], -1) {
+			value := html.UnescapeString(string(attr[2]) + string(attr[3]))
+
+			switch strings.ToLower(string(attr[1])) {
+			case "href":
+				file.Url = value
+
+			case "data-requires-python":
+				file.RequiresPython = value
+
+			case "data-yanked":
+				if value == "" {
+					file.Yanked = true
+				} else {
+					file.Yanked = value
+				}
+			}
+		}
+
+		if file.Filename == "" && file.Url != "" {
+			if u, err := url.Parse(file.Url); err == nil {
+				file.Filename = path.Base(u.Path)
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files
+}
+
+// simpleReleases returns the releases of the project,
+// with the versions derived from the filenames of the distribution files.
+// A release is considered as yanked if all its files are yanked.
+func simpleReleases(project string, files []simpleFile) []ReleaseInfo {
+	var releases []ReleaseInfo
+
+	indexes := make(map[string]int)
+
+	for _, file := range files {
+		version, ok := distributionVersion(project, file.Filename)
+
+		if !ok {
+			log.Debugf("Ignoring distribution file for %s: %s", project, file.Filename)
+
+			continue
+		}
+
+		i, found := indexes[version]
+
+		if !found {
+			i = len(releases)
+			indexes[version] = i
+
+			releases = append(releases, ReleaseInfo{
+				Version: fmt.Sprintf("v%s", version),
+				Yanked:  true,
+			})
+		}
+
+		release := &releases[i]
+
+		if release.RequiresPython == "" {
+			release.RequiresPython = file.RequiresPython
+		}
+
+		yanked, reason := file.yanked()
+
+		if !yanked {
+			release.Yanked = false
+			release.YankedReason = ""
+		} else if release.Yanked && release.YankedReason == "" {
+			release.YankedReason = reason
+		}
+	}
+
+	return releases
+}
+
+// yanked returns whether the file has been yanked, and why (if specified).
+func (f simpleFile) yanked() (bool, string) {
+	switch y := f.Yanked.(type) {
+	case bool:
+		return y, ""
+
+	case string:
+		return true, y
+	}
+
+	return false, ""
+}
+
+// distributionVersion returns the canonical version of a distribution file
+// of the project, derived from its filename: either a wheel
+// (`name-version(-build)?-python-abi-platform.whl`), an egg or a source distribution
+// (`name-version.tar.gz`, `.zip`, ...).
+func distributionVersion(project string, filename string) (string, bool) {
+	var version string
+
+	switch {
+	case strings.HasSuffix(filename, ".whl"), strings.HasSuffix(filename, ".egg"):
+		parts := strings.Split(strings.TrimSuffix(filename, path.Ext(filename)), "-")
+
+		if len(parts) < 2 {
+			return "", false
+		}
+
+		version = parts[1]
+
+	default:
+		stem := ""
+
+		for _, ext := range sdistExtensions {
+			if strings.HasSuffix(filename, ext) {
+				stem = strings.TrimSuffix(filename, ext)
+				break
+			}
+		}
+
+		// The project name can contain `-`, so look for the matching prefix
+		name := NormalizePackageName(project)
+
+		for i := strings.Index(stem, "-"); i >= 0; {
+			if NormalizePackageName(stem[:i]) == name {
+				version = stem[i+1:]
+				break
+			}
+
+			next := strings.Index(stem[i+1:], "-")
+
+			if next < 0 {
+				break
+			}
+
+			i += next + 1
+		}
+	}
+
+	v, err := ParseVersion(version)
+
+	if err != nil {
+		return "", false
+	}
+
+	return v.String(), true
+}
+
+// redactedUrl returns the URL with its password (if any) masked,
+// so that the credentials are not reported.
+func redactedUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+
+	if err != nil {
+		return rawUrl
+	}
+
+	return u.Redacted()
+}
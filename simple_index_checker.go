@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// SimpleIndexChecker checks the packages against an index
+// implementing the Simple Repository API (see PEP 503 and PEP 691),
+// e.g. a devpi, Nexus or Artifactory proxy.
+type SimpleIndexChecker struct {
+	// Name of the index, reported as registry (the host of its URL if empty)
+	Name string
+
+	// Base URL of the index (e.g. `https://pypi.org/simple`)
+	IndexUrl string
+
+	PythonRequirement VersionRequirement
+
+	// Policy about the pre-releases as candidate versions
+	Prereleases PrereleasePolicy
+
+	// HTTP client to query the index (the default one if nil)
+	Client *http.Client
+}
+
+// RequiredUpdate checks if a package requires an update,
+// according the releases found on the index.
+func (c SimpleIndexChecker) RequiredUpdate(
+	pkg string,
+	requirement VersionRequirement,
+) (CheckResult, error) {
+	info, err := GetSimpleProjectInfo(c.Client, c.IndexUrl, pkg)
+
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	if info == nil {
+		return CheckResult{NotFound: true}, nil
+	}
+
+	return c.checkProject(*info, requirement)
+}
+
+// checkProject checks the project information against the requirement,
+// the same way as for the PyPI JSON API.
+func (c SimpleIndexChecker) checkProject(
+	info ProjectInfo,
+	requirement VersionRequirement,
+) (CheckResult, error) {
+	checker := PypiChecker{
+		PythonRequirement: c.PythonRequirement,
+		Prereleases:       c.Prereleases,
+	}
+
+	result, err := checker.checkProject(info, requirement)
+
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	result.Registry = c.registryName()
+
+	return result, nil
+}
+
+// registryName returns the name of the index as reported registry
+// (the host of its URL if not named).
+func (c SimpleIndexChecker) registryName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	if u, err := url.Parse(c.IndexUrl); err == nil && u.Host != "" {
+		return u.Host
+	}
+
+	return c.IndexUrl
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSimpleIndexCheckProject(t *testing.T) {
+	checker := SimpleIndexChecker{IndexUrl: "https://nexus.example.com/repository/pypi/simple"}
+
+	info := ProjectInfo{
+		Name: "foo-bar",
+		Releases: []ReleaseInfo{
+			{Version: "v1.0.0"},
+			{Version: "v1.1.0"},
+			{Version: "v2.0.0rc1"},
+		},
+	}
+
+	result, err := checker.checkProject(info, VersionRequirement{{"==", "v1.0.0"}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	expected := CheckResult{
+		LatestVersion:      "v1.1.0",
+		InstallableVersion: "v1.1.0",
+		UpdateLevel:        Minor,
+		Registry:           "nexus.example.com",
+	}
+
+	if result != expected {
+		t.Errorf("Expected %v, but got %v", expected, result)
+	}
+}
+
+func TestSimpleIndexCheckerNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	checker := SimpleIndexChecker{IndexUrl: server.URL + "/simple", Client: server.Client()}
+
+	result, err := checker.RequiredUpdate("reqeusts", VersionRequirement{{"==", "v1.0.0"}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if result != (CheckResult{NotFound: true}) {
+		t.Errorf("Expected package not found, but got %+v", result)
+	}
+}
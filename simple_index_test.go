@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const simpleHtmlPage = `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Links for foo-bar</h1>
+    <a href="/files/foo_bar-1.0.0.tar.gz#sha256=abc">foo_bar-1.0.0.tar.gz</a><br/>
+    <a href="/files/foo_bar-1.0.0-py3-none-any.whl" data-requires-python="&gt;=3.6">foo_bar-1.0.0-py3-none-any.whl</a><br/>
+    <a href="/files/foo_bar-1.1.0-py3-none-any.whl" data-requires-python="&gt;=3.8" data-yanked="broken build">foo_bar-1.1.0-py3-none-any.whl</a><br/>
+    <a href="/files/Foo.Bar-1.2.0rc1.zip" data-requires-python="&gt;=3.8">Foo.Bar-1.2.0rc1.zip</a><br/>
+    <a href="/files/foo_bar-1.1.1-1-cp311-cp311-manylinux_2_17_x86_64.whl" data-requires-python='&gt;=3.9'>foo_bar-1.1.1-1-cp311-cp311-manylinux_2_17_x86_64.whl</a><br/>
+    <a href="/files/foo_bar-1.1.1.tar.gz" data-yanked="">foo_bar-1.1.1.tar.gz</a><br/>
+    <a href="/files/foo_bar-README.txt">foo_bar-README.txt</a><br/>
+  </body>
+</html>`
+
+const simpleJsonPage = `{
+  "meta": {"api-version": "1.1"},
+  "name": "foo-bar",
+  "files": [
+    {"filename": "foo_bar-1.0.0.tar.gz", "url": "/files/foo_bar-1.0.0.tar.gz", "hashes": {}},
+    {"filename": "foo_bar-1.0.0-py3-none-any.whl", "url": "/files/foo_bar-1.0.0-py3-none-any.whl", "hashes": {}, "requires-python": ">=3.6"},
+    {"filename": "foo_bar-1.1.0-py3-none-any.whl", "url": "/files/foo_bar-1.1.0-py3-none-any.whl", "hashes": {}, "requires-python": ">=3.8", "yanked": "broken build"},
+    {"filename": "Foo.Bar-1.2.0rc1.zip", "url": "/files/Foo.Bar-1.2.0rc1.zip", "hashes": {}, "requires-python": ">=3.8"},
+    {"filename": "foo_bar-1.1.1-1-cp311-cp311-manylinux_2_17_x86_64.whl", "url": "/files/x.whl", "hashes": {}, "requires-python": ">=3.9", "yanked": false},
+    {"filename": "foo_bar-1.1.1.tar.gz", "url": "/files/foo_bar-1.1.1.tar.gz", "hashes": {}, "yanked": true}
+  ]
+}`
+
+func TestDistributionVersion(t *testing.T) {
+	tests := []struct {
+		project  string
+		filename string
+		expected string
+	}{
+		{"requests", "requests-2.31.0.tar.gz", "2.31.0"},
+		{"requests", "requests-2.31.0-py3-none-any.whl", "2.31.0"},
+		{"zope.interface", "zope.interface-6.0-cp311-cp311-win_amd64.whl", "6.0"},
+		{"zope.interface", "zope.interface-6.0.tar.gz", "6.0"},
+		{"python-dateutil", "python-dateutil-2.8.2.tar.gz", "2.8.2"},
+		{"python-dateutil", "python_dateutil-2.8.2-py2.py3-none-any.whl", "2.8.2"},
+		{"python-dateutil", "Python-DateUtil-2.8.2.zip", "2.8.2"},
+		{"python-dateutil", "python-dateutil-2.9.0.post0.tar.gz", "2.9.0.post0"},
+		{"python-dateutil", "python-dateutil-3.0rc1.tar.bz2", "3.0rc1"},
+		{"python-dateutil", "python_dateutil-1.5-py2.7.egg", "1.5"},
+		{"python-dateutil", "python-dateutil-README.txt", ""},
+		{"python-dateutil", "python-dateutil-latest.tar.gz", ""},
+		{"python-dateutil", "other-1.0.tar.gz", ""},
+	}
+
+	for _, test := range tests {
+		version, ok := distributionVersion(test.project, test.filename)
+
+		if ok != (test.expected != "") || version != test.expected {
+			t.Errorf("For '%s', expected version '%s', but got '%s' (%v)",
+				test.filename, test.expected, version, ok)
+		}
+	}
+}
+
+func TestGetSimpleProjectInfo(t *testing.T) {
+	expectedReleases := []ReleaseInfo{
+		{Version: "v1.0.0", RequiresPython: ">=3.6"},
+		{Version: "v1.1.0", RequiresPython: ">=3.8", Yanked: true, YankedReason: "broken build"},
+		{Version: "v1.2.0rc1", RequiresPython: ">=3.8"},
+		{Version: "v1.1.1", RequiresPython: ">=3.9"},
+	}
+
+	for _, format := range []string{"html", "json"} {
+		var accept string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accept = r.Header.Get("Accept")
+
+			if r.URL.Path != "/simple/foo-bar/" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			if format == "json" {
+				w.Header().Set("Content-Type", simpleJsonMediaType)
+				io.WriteString(w, simpleJsonPage)
+			} else {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				io.WriteString(w, simpleHtmlPage)
+			}
+		}))
+
+		info, err := GetSimpleProjectInfo(nil, server.URL+"/simple/", "Foo_Bar")
+
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", format, err.Error())
+		}
+
+		if accept == "" {
+			t.Errorf("Expected Accept header for %s", format)
+		}
+
+		if info == nil {
+			t.Fatalf("Expected project info for %s", format)
+		}
+
+		if info.Version != "v1.1.1" || info.RequiresPython != ">=3.9" {
+			t.Errorf("For %s, unexpected latest version %s (requires %s)",
+				format, info.Version, info.RequiresPython)
+		}
+
+		if info.HomeURL != server.URL+"/simple/foo-bar/" {
+			t.Errorf("For %s, unexpected URL: %s", format, info.HomeURL)
+		}
+
+		if !reflect.DeepEqual(info.Releases, expectedReleases) {
+			t.Errorf("For %s, expected releases %v, but got %v",
+				format, expectedReleases, info.Releases)
+		}
+
+		info, err = GetSimpleProjectInfo(nil, server.URL+"/simple", "missing")
+
+		if err != nil || info != nil {
+			t.Errorf("For %s, expected no project info for missing package, but got %v (%v)",
+				format, info, err)
+		}
+
+		server.Close()
+	}
+}
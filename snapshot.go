@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// OfflineTransport is an HTTP transport resolving the requests
+// to the PyPI JSON API (`.../pypi/<name>/json`) from a local directory,
+// without any network access.
+//
+// The directory can be either a snapshot exported by wilf (see SnapshotTransport),
+// or the `web` directory of a [bandersnatch](https://pypi.org/project/bandersnatch/)
+// mirror with the JSON API enabled (`web/pypi/<name>/json` or `web/json/<name>`).
+type OfflineTransport struct {
+	Dir string
+}
+
+// SnapshotTransport is an HTTP transport exporting the successful responses
+// of the PyPI JSON API to a local directory, so that it can be used later
+// as snapshot in offline mode (see OfflineTransport).
+type SnapshotTransport struct {
+	// Directory where the snapshot is exported
+	Dir string
+
+	// Transport used to send the requests (http.DefaultTransport if nil)
+	Transport http.RoundTripper
+}
+
+// NewOfflineClient returns an HTTP client resolving the PyPI metadata
+// from the given directory.
+func NewOfflineClient(dir string) *http.Client {
+	return &http.Client{Transport: &OfflineTransport{Dir: dir}}
+}
+
+// RoundTrip is a method of the http.RoundTripper interface.
+func (t *OfflineTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	name, ok := pypiJsonPackage(request)
+
+	if !ok {
+		return nil, fmt.Errorf("%s is not available in offline mode", request.URL.Redacted())
+	}
+
+	for _, path := range snapshotPaths(t.Dir, name) {
+		body, err := os.ReadFile(path)
+
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("fails to read offline snapshot '%s': %s", path, err.Error())
+		}
+
+		log.Debugf("Using offline snapshot for %s: %s", name, path)
+
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("package %s not found in offline snapshot '%s'", name, t.Dir)
+}
+
+// RoundTrip is a method of the http.RoundTripper interface.
+func (t *SnapshotTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport := t.Transport
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	response, err := transport.RoundTrip(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name, ok := pypiJsonPackage(request)
+
+	if !ok || response.StatusCode != http.StatusOK {
+		return response, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	path := snapshotPaths(t.Dir, name)[0]
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("fails to export snapshot for %s: %s", name, err.Error())
+	}
+
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return nil, fmt.Errorf("fails to export snapshot for %s: %s", name, err.Error())
+	}
+
+	log.Debugf("Snapshot exported for %s: %s", name, path)
+
+	return response, nil
+}
+
+// pypiJsonPackage returns the name of the package
+// if the request is for the PyPI JSON API (`.../pypi/<name>/json`).
+func pypiJsonPackage(request *http.Request) (string, bool) {
+	if request.Method != http.MethodGet {
+		return "", false
+	}
+
+	// The JSON API can be served under a base path (e.g. by a proxy)
+	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+	n := len(parts)
+
+	if n < 3 || parts[n-3] != "pypi" || parts[n-1] != "json" || parts[n-2] == "" {
+		return "", false
+	}
+
+	return parts[n-2], true
+}
+
+// snapshotPaths returns the paths where the JSON document of the package
+// can be found in the snapshot directory, the first one being
+// where it's exported.
+func snapshotPaths(dir string, name string) []string {
+	normalized := NormalizePackageName(name)
+
+	paths := []string{
+		filepath.Join(dir, "pypi", normalized, "json"),
+		filepath.Join(dir, "json", normalized),
+	}
+
+	if normalized != name {
+		paths = append(paths,
+			filepath.Join(dir, "pypi", name, "json"),
+			filepath.Join(dir, "json", name),
+		)
+	}
+
+	return paths
+}
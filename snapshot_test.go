@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const snapshotDocument = `{
+  "info": {"name": "Foo_Bar", "version": "1.2.0", "requires_python": ">=3.8"},
+  "releases": {
+    "1.1.0": [{"requires_python": ">=3.8", "yanked": false}],
+    "1.2.0": [{"requires_python": ">=3.8", "yanked": false}]
+  }
+}`
+
+func TestSnapshotTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pypi/Foo_Bar/json" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "Not Found"}`)
+
+			return
+		}
+
+		io.WriteString(w, snapshotDocument)
+	}))
+
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: &SnapshotTransport{Dir: dir}}
+
+	for _, path := range []string{"/pypi/Foo_Bar/json", "/pypi/missing/json", "/simple/"} {
+		response, err := client.Get(server.URL + path)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if path == "/pypi/Foo_Bar/json" && string(body) != snapshotDocument {
+			t.Errorf("Unexpected body: %s", body)
+		}
+	}
+
+	// Only the successful JSON documents are exported
+	var exported []string
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			exported = append(exported, filepath.ToSlash(rel))
+		}
+
+		return nil
+	})
+
+	if len(exported) != 1 || exported[0] != "pypi/foo-bar/json" {
+		t.Errorf("Expected only pypi/foo-bar/json to be exported, but got %v", exported)
+	}
+
+	// The exported snapshot can be used in offline mode
+	info, err := GetProjectInfo(NewOfflineClient(dir), "", "foo.bar")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if info == nil || info.Version != "v1.2.0" || len(info.Releases) != 2 {
+		t.Errorf("Unexpected project info: %v", info)
+	}
+}
+
+func TestOfflineTransport(t *testing.T) {
+	// Bandersnatch layout: web/json/<name>
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(dir, "json"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "json", "foo-bar"), []byte(snapshotDocument), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewOfflineClient(dir)
+
+	info, err := GetProjectInfo(client, "", "Foo_Bar")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if info == nil || info.Version != "v1.2.0" {
+		t.Errorf("Unexpected project info: %v", info)
+	}
+
+	// A package missing from the snapshot is an error (not a package not found)
+	_, err = GetProjectInfo(client, "", "missing")
+
+	if err == nil || !strings.Contains(err.Error(), "package missing not found in offline snapshot") {
+		t.Errorf("Expected error for missing package, but got %v", err)
+	}
+
+	// Other registries are not available
+	_, err = GetGitlabProjectInfo(client, GitlabRegistryConfig{
+		ProjectApiPackagesUrl: "https://gitlab.com/api/v4/projects/1/packages",
+	}, "foo-bar")
+
+	if err == nil || !strings.Contains(err.Error(), "not available in offline mode") {
+		t.Errorf("Expected error for Gitlab registry, but got %v", err)
+	}
+}
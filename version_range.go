@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VersionBound is either the lower or the upper bound of a VersionInterval.
+type VersionBound struct {
+	// No bound (infinite) if Unbounded, otherwise the Version
+	Unbounded bool
+	Version   Version
+	Inclusive bool
+}
+
+// VersionInterval is an interval of versions,
+// e.g. `[1.0, 2.0)` for `>=1.0,<2.0`.
+type VersionInterval struct {
+	Lower VersionBound
+	Upper VersionBound
+}
+
+// VersionRange is a set of versions, as sorted and disjoint intervals.
+// An empty range contains no version.
+type VersionRange []VersionInterval
+
+var unbounded = VersionBound{Unbounded: true}
+
+// AnyVersionRange returns the range containing all the versions.
+func AnyVersionRange() VersionRange {
+	return VersionRange{VersionInterval{Lower: unbounded, Upper: unbounded}}
+}
+
+// NewVersionRange returns the range of the versions
+// matching all the constraints of the given requirement.
+//
+// The versions are considered according the PEP 440 ordering,
+// but the exclusive ordered comparisons (`<` and `>`) are simplified
+// as strict bounds (e.g. `<2.0` contains `2.0rc1`).
+func NewVersionRange(requirement VersionRequirement) (VersionRange, error) {
+	versionRange := AnyVersionRange()
+
+	for _, constraint := range requirement {
+		r, err := constraintRange(constraint)
+
+		if err != nil {
+			return nil, err
+		}
+
+		versionRange = versionRange.Intersect(r)
+	}
+
+	return versionRange, nil
+}
+
+// constraintRange returns the range of the versions matching the constraint.
+func constraintRange(constraint VersionConstraint) (VersionRange, error) {
+	op := constraint[0]
+
+	if op == "*" {
+		return AnyVersionRange(), nil
+	}
+
+	spec := constraint[1]
+
+	if op == "~" || op == "!~" {
+		// Prefix matching, e.g. `1.2.*` as `>=1.2.dev0,<1.3.dev0`
+		prefix, err := ParseVersion(strings.TrimSuffix(spec, ".*"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		r := VersionRange{VersionInterval{
+			Lower: VersionBound{Version: firstOfRelease(prefix), Inclusive: true},
+			Upper: VersionBound{Version: firstOfRelease(nextRelease(prefix))},
+		}}
+
+		if op == "!~" {
+			return r.Complement(), nil
+		}
+
+		return r, nil
+	}
+
+	v, err := ParseVersion(spec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bound := VersionBound{Version: v, Inclusive: true}
+
+	switch op {
+	case "==", "===":
+		return VersionRange{VersionInterval{Lower: bound, Upper: bound}}, nil
+
+	case "!=":
+		return VersionRange{VersionInterval{Lower: bound, Upper: bound}}.Complement(), nil
+
+	case ">=":
+		return VersionRange{VersionInterval{Lower: bound, Upper: unbounded}}, nil
+
+	case "<=":
+		return VersionRange{VersionInterval{Lower: unbounded, Upper: bound}}, nil
+
+	case ">":
+		bound.Inclusive = false
+
+		return VersionRange{VersionInterval{Lower: bound, Upper: unbounded}}, nil
+
+	case "<":
+		bound.Inclusive = false
+
+		return VersionRange{VersionInterval{Lower: unbounded, Upper: bound}}, nil
+
+	case "~=":
+		// Compatible release, e.g. `~=2.2` as `>=2.2,==2.*`
+		if len(v.Release) < 2 {
+			return nil, fmt.Errorf("invalid compatible release: %s%s", op, spec)
+		}
+
+		prefix := Version{Epoch: v.Epoch, Release: v.Release[:len(v.Release)-1]}
+
+		return VersionRange{VersionInterval{
+			Lower: bound,
+			Upper: VersionBound{Version: firstOfRelease(nextRelease(prefix))},
+		}}, nil
+	}
+
+	return nil, fmt.Errorf("invalid operator: %s", op)
+}
+
+// firstOfRelease returns the lowest version for the same epoch and release
+// (i.e. the first development release).
+func firstOfRelease(v Version) Version {
+	return Version{Epoch: v.Epoch, Release: v.Release, HasDev: true}
+}
+
+// nextRelease returns the release following the given one
+// at the same level (e.g. `1.3` for `1.2`).
+func nextRelease(v Version) Version {
+	release := append([]int{}, v.Release...)
+	release[len(release)-1]++
+
+	return Version{Epoch: v.Epoch, Release: release}
+}
+
+// IsEmpty returns true if the range contains no version.
+func (r VersionRange) IsEmpty() bool {
+	return len(r) == 0
+}
+
+// Contains checks whether the version is in the range.
+func (r VersionRange) Contains(v Version) bool {
+	point := VersionBound{Version: v, Inclusive: true}
+
+	return !r.Intersect(VersionRange{VersionInterval{Lower: point, Upper: point}}).IsEmpty()
+}
+
+// Intersect returns the range of the versions which are in both ranges.
+func (r VersionRange) Intersect(o VersionRange) VersionRange {
+	var intervals []VersionInterval
+
+	for _, a := range r {
+		for _, b := range o {
+			i := VersionInterval{Lower: a.Lower, Upper: a.Upper}
+
+			if compareLowerBounds(b.Lower, i.Lower) > 0 {
+				i.Lower = b.Lower
+			}
+
+			if compareUpperBounds(b.Upper, i.Upper) < 0 {
+				i.Upper = b.Upper
+			}
+
+			if !i.isEmpty() {
+				intervals = append(intervals, i)
+			}
+		}
+	}
+
+	return normalizeIntervals(intervals)
+}
+
+// Union returns the range of the versions which are in either range.
+func (r VersionRange) Union(o VersionRange) VersionRange {
+	intervals := append(append([]VersionInterval{}, r...), o...)
+
+	return normalizeIntervals(intervals)
+}
+
+// Complement returns the range of the versions which are not in the range.
+func (r VersionRange) Complement() VersionRange {
+	var intervals []VersionInterval
+
+	lower := unbounded
+
+	for _, i := range normalizeIntervals(r) {
+		if !i.Lower.Unbounded {
+			intervals = append(intervals, VersionInterval{
+				Lower: lower,
+				Upper: VersionBound{Version: i.Lower.Version, Inclusive: !i.Lower.Inclusive},
+			})
+		}
+
+		if i.Upper.Unbounded {
+			return normalizeIntervals(intervals)
+		}
+
+		lower = VersionBound{Version: i.Upper.Version, Inclusive: !i.Upper.Inclusive}
+	}
+
+	intervals = append(intervals, VersionInterval{Lower: lower, Upper: unbounded})
+
+	return normalizeIntervals(intervals)
+}
+
+// IsSubsetOf checks whether all the versions of the range are also in the other one.
+func (r VersionRange) IsSubsetOf(o VersionRange) bool {
+	return r.Intersect(o.Complement()).IsEmpty()
+}
+
+// String returns a representation of the range using the interval notation,
+// e.g. `[1.0, 2.0) ∪ [3.0, ∞)`.
+func (r VersionRange) String() string {
+	if r.IsEmpty() {
+		return "∅"
+	}
+
+	parts := make([]string, len(r))
+
+	for n, i := range r {
+		var b strings.Builder
+
+		if i.Lower.Unbounded {
+			b.WriteString("(-∞")
+		} else if i.Lower.Inclusive {
+			fmt.Fprintf(&b, "[%s", i.Lower.Version)
+		} else {
+			fmt.Fprintf(&b, "(%s", i.Lower.Version)
+		}
+
+		if i.Upper.Unbounded {
+			b.WriteString(", ∞)")
+		} else if i.Upper.Inclusive {
+			fmt.Fprintf(&b, ", %s]", i.Upper.Version)
+		} else {
+			fmt.Fprintf(&b, ", %s)", i.Upper.Version)
+		}
+
+		parts[n] = b.String()
+	}
+
+	return strings.Join(parts, " ∪ ")
+}
+
+func (i VersionInterval) isEmpty() bool {
+	if i.Lower.Unbounded || i.Upper.Unbounded {
+		return false
+	}
+
+	c := i.Lower.Version.Compare(i.Upper.Version)
+
+	return c > 0 || (c == 0 && !(i.Lower.Inclusive && i.Upper.Inclusive))
+}
+
+// normalizeIntervals sorts the non-empty intervals, and merges
+// the ones which are overlapping or adjacent.
+func normalizeIntervals(intervals []VersionInterval) VersionRange {
+	sorted := make([]VersionInterval, 0, len(intervals))
+
+	for _, i := range intervals {
+		if !i.isEmpty() {
+			sorted = append(sorted, i)
+		}
+	}
+
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return compareLowerBounds(sorted[a].Lower, sorted[b].Lower) < 0
+	})
+
+	r := VersionRange{}
+
+	for _, i := range sorted {
+		if n := len(r); n > 0 && r[n-1].joins(i) {
+			if compareUpperBounds(i.Upper, r[n-1].Upper) > 0 {
+				r[n-1].Upper = i.Upper
+			}
+
+			continue
+		}
+
+		r = append(r, i)
+	}
+
+	return r
+}
+
+// joins checks whether the interval overlaps or is adjacent
+// to the next one (which doesn't start before).
+func (i VersionInterval) joins(next VersionInterval) bool {
+	if i.Upper.Unbounded || next.Lower.Unbounded {
+		return true
+	}
+
+	c := i.Upper.Version.Compare(next.Lower.Version)
+
+	return c > 0 || (c == 0 && (i.Upper.Inclusive || next.Lower.Inclusive))
+}
+
+// compareLowerBounds compares two lower bounds,
+// an unbounded one being the lowest.
+func compareLowerBounds(a, b VersionBound) int {
+	if a.Unbounded || b.Unbounded {
+		return compareBools(!a.Unbounded, !b.Unbounded)
+	}
+
+	if c := a.Version.Compare(b.Version); c != 0 {
+		return c
+	}
+
+	// An inclusive lower bound starts before an exclusive one
+	return compareBools(!a.Inclusive, !b.Inclusive)
+}
+
+// compareUpperBounds compares two upper bounds,
+// an unbounded one being the greatest.
+func compareUpperBounds(a, b VersionBound) int {
+	if a.Unbounded || b.Unbounded {
+		return compareBools(a.Unbounded, b.Unbounded)
+	}
+
+	if c := a.Version.Compare(b.Version); c != 0 {
+		return c
+	}
+
+	// An inclusive upper bound ends after an exclusive one
+	return compareBools(a.Inclusive, b.Inclusive)
+}
+
+// compareBools compares two booleans, false being lower than true.
+func compareBools(a, b bool) int {
+	if a == b {
+		return 0
+	}
+
+	if a {
+		return 1
+	}
+
+	return -1
+}
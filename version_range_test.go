@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNewVersionRange(t *testing.T) {
+	tests := []struct {
+		requirement string
+		expected    string
+	}{
+		{"*", "(-∞, ∞)"},
+		{">=3.8", "[3.8, ∞)"},
+		{">=3.6,<3.10", "[3.6, 3.10)"},
+		{">3.6,<=3.10", "(3.6, 3.10]"},
+		{"==3.8", "[3.8, 3.8]"},
+		{"!=3.8", "(-∞, 3.8) ∪ (3.8, ∞)"},
+		{"==3.9.*", "[3.9.dev0, 3.10.dev0)"},
+		{">=3.6,!=3.9.*", "[3.6, 3.9.dev0) ∪ [3.10.dev0, ∞)"},
+		{"~=2.2", "[2.2, 3.dev0)"},
+		{"~=1.4.5", "[1.4.5, 1.5.dev0)"},
+		{">=3.10,<3.6", "∅"},
+		{"==3.8,!=3.8", "∅"},
+	}
+
+	for _, test := range tests {
+		req, err := ParseVersionRequirement(test.requirement)
+
+		if err != nil {
+			t.Fatalf("Unexpected error for '%s': %s", test.requirement, err.Error())
+		}
+
+		r, err := NewVersionRange(req)
+
+		if err != nil {
+			t.Errorf("Unexpected error for '%s': %s", test.requirement, err.Error())
+			continue
+		}
+
+		if r.String() != test.expected {
+			t.Errorf("For '%s', expected range %s, but got %s",
+				test.requirement, test.expected, r.String())
+		}
+	}
+
+	if _, err := NewVersionRange(VersionRequirement{{"<>", "v1.0"}}); err == nil {
+		t.Errorf("Expected error for invalid operator")
+	}
+}
+
+func TestVersionRangeOperations(t *testing.T) {
+	parse := func(s string) VersionRange {
+		req, err := ParseVersionRequirement(s)
+
+		if err != nil {
+			t.Fatalf("Unexpected error for '%s': %s", s, err.Error())
+		}
+
+		r, err := NewVersionRange(req)
+
+		if err != nil {
+			t.Fatalf("Unexpected error for '%s': %s", s, err.Error())
+		}
+
+		return r
+	}
+
+	tests := []struct {
+		a            string
+		b            string
+		intersection string
+		union        string
+		subset       bool
+	}{
+		{">=3.8", ">=3.6,<3.10", "[3.8, 3.10)", "[3.6, ∞)", false},
+		{">=3.7,<3.9", ">=3.6", "[3.7, 3.9)", "[3.6, ∞)", true},
+		{"<3.6", ">=3.6", "∅", "(-∞, ∞)", false},
+		{"<=3.6", ">3.6", "∅", "(-∞, ∞)", false},
+		{"<3.6", ">3.6", "∅", "(-∞, 3.6) ∪ (3.6, ∞)", false},
+		{"==3.9.*", "!=3.9.*", "∅", "(-∞, ∞)", false},
+		{"==3.9.1", ">=3.6,!=3.9.*", "∅", "[3.6, 3.9.dev0) ∪ [3.9.1, 3.9.1] ∪ [3.10.dev0, ∞)", false},
+		{"==3.11.4", ">=3.6,!=3.9.*", "[3.11.4, 3.11.4]", "[3.6, 3.9.dev0) ∪ [3.10.dev0, ∞)", true},
+	}
+
+	for _, test := range tests {
+		a := parse(test.a)
+		b := parse(test.b)
+
+		if i := a.Intersect(b).String(); i != test.intersection {
+			t.Errorf("For '%s' ∩ '%s', expected %s, but got %s", test.a, test.b, test.intersection, i)
+		}
+
+		if i := b.Intersect(a).String(); i != test.intersection {
+			t.Errorf("For '%s' ∩ '%s', expected %s, but got %s", test.b, test.a, test.intersection, i)
+		}
+
+		if u := a.Union(b).String(); u != test.union {
+			t.Errorf("For '%s' ∪ '%s', expected %s, but got %s", test.a, test.b, test.union, u)
+		}
+
+		if s := a.IsSubsetOf(b); s != test.subset {
+			t.Errorf("For '%s' ⊆ '%s', expected %v, but got %v", test.a, test.b, test.subset, s)
+		}
+
+		if !a.Union(a.Complement()).IsSubsetOf(AnyVersionRange()) ||
+			!AnyVersionRange().IsSubsetOf(a.Union(a.Complement())) {
+			t.Errorf("Expected '%s' ∪ its complement to contain all versions", test.a)
+		}
+
+		if !a.Intersect(a.Complement()).IsEmpty() {
+			t.Errorf("Expected '%s' ∩ its complement to be empty", test.a)
+		}
+	}
+
+	r := parse(">=3.6,!=3.9.*")
+
+	for version, expected := range map[string]bool{
+		"3.5.9":   false,
+		"3.6":     true,
+		"3.8.18":  true,
+		"3.9.0":   false,
+		"3.9.0a1": false,
+		"3.10.0":  true,
+	} {
+		v, _ := ParseVersion(version)
+
+		if c := r.Contains(v); c != expected {
+			t.Errorf("For %s in %s, expected %v, but got %v", version, r, expected, c)
+		}
+	}
+}